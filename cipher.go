@@ -5,25 +5,37 @@ import (
 
 	"github.com/cyrildever/feistel/common/padding"
 	"github.com/cyrildever/feistel/common/utils"
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/common/utils/runes"
 	"github.com/cyrildever/feistel/exception"
-	utls "github.com/cyrildever/go-utls/common/utils"
+	"github.com/cyrildever/feistel/keys"
 	"github.com/cyrildever/go-utls/common/xor"
 )
 
 //--- TYPES
 
-// Cipher uses the SHA-256 hashing function to create the keys at each round.
+// Cipher uses the hash engine to create the keys at each round, SHA-256 being the default for compatibility with the other implementations.
 // NB: There must be at least 2 rounds.
+//
+// If set, the Unicode `Normalization` form is applied to the source before encryption and to the deciphered string.
+// The `Round` function, its `Expansion` and its `Mask` default to the legacy ones.
+// The `Schedule` of the round keys defaults to the legacy MasterKey built from the `Key`.
 type Cipher struct {
-	Key    string
-	Rounds int
+	Key           string
+	Rounds        int
+	Normalization runes.Normalization
+	Round         Round
+	Expansion     Expansion
+	Engine        hash.Engine
+	Mask          Mask
+	Schedule      KeySchedule
 }
 
 //--- METHODS
 
 // Encrypt ...
 func (c Cipher) Encrypt(src string) (ciphered []byte, err error) {
-	if len(c.Key) == 0 || c.Rounds < 2 {
+	if !c.isValid() {
 		err = exception.NewWrongCipherParametersError()
 		return
 	}
@@ -31,7 +43,7 @@ func (c Cipher) Encrypt(src string) (ciphered []byte, err error) {
 		return
 	}
 	// Apply the balanced Feistel cipher
-	data := padding.Apply([]byte(src))
+	data := padding.Apply([]byte(c.Normalization.Apply(src)))
 	if len(data)%2 != 0 {
 		err = errors.New("invalid string length: cannot be split")
 		return
@@ -40,10 +52,12 @@ func (c Cipher) Encrypt(src string) (ciphered []byte, err error) {
 	if err != nil {
 		return
 	}
+	net := c.prepare()
+	defer net.destroy()
 	parts := []string{left, right}
 	for i := 0; i < c.Rounds; i++ {
 		left = right
-		rnd, e := c.round(parts[1], i)
+		rnd, e := net.round(parts[1], i)
 		if e != nil {
 			err = e
 			return
@@ -60,7 +74,7 @@ func (c Cipher) Encrypt(src string) (ciphered []byte, err error) {
 
 // Decrypt ...
 func (c Cipher) Decrypt(ciphered []byte) (string, error) {
-	if len(c.Key) == 0 || c.Rounds < 2 {
+	if !c.isValid() {
 		return "", exception.NewWrongCipherParametersError()
 	}
 	if len(ciphered) == 0 {
@@ -74,8 +88,10 @@ func (c Cipher) Decrypt(ciphered []byte) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	net := c.prepare()
+	defer net.destroy()
 	for i := 0; i < c.Rounds; i++ {
-		rnd, err := c.round(left, c.Rounds-i-1)
+		rnd, err := net.round(left, c.Rounds-i-1)
 		if err != nil {
 			return "", err
 		}
@@ -86,31 +102,58 @@ func (c Cipher) Decrypt(ciphered []byte) (string, error) {
 		right = left
 		left = tmp
 	}
-	return string(padding.Unapply([]byte(left + right))), nil
+	return c.Normalization.Apply(string(padding.Unapply([]byte(left + right)))), nil
 }
 
 // Feistel implementation
 
-// round is the function applied at each round of the obfuscation process to the right side of the Feistel cipher
-func (c Cipher) round(item string, index int) (string, error) {
-	addition, err := utils.Add(item, utils.Extract(c.Key, index, len(item)))
-	if err != nil {
-		return "", err
-	}
-	hashed, err := utils.Hash([]byte(addition))
-	if err != nil {
-		return "", err
+// prepare returns the network holding the function applied at each round of the obfuscation process to the right side of the Feistel cipher
+func (c Cipher) prepare() *network {
+	return newNetwork(c.Round, c.Expansion, c.Mask, orDefault(c.Engine), c.schedule())
+}
+
+func (c Cipher) schedule() KeySchedule {
+	if c.Schedule != nil {
+		return c.Schedule
 	}
-	hexHashed := utls.ToHex(hashed)
-	return utils.Extract(hexHashed, index, len(item)), nil
+	return MasterKey(c.Key)
+}
+
+func (c Cipher) isValid() bool {
+	return (len(c.Key) > 0 || c.Schedule != nil) && c.Rounds >= 2 && hash.IsAvailableEngine(orDefault(c.Engine)) && c.Normalization.IsValid() && c.Round.IsValid() && c.Expansion.IsValid() && c.Mask.IsValid()
 }
 
 //--- FUNCTIONS
 
 // NewCipher ...
 func NewCipher(key string, rounds int) *Cipher {
+	return NewCipherWithEngine(hash.SHA_256, key, rounds)
+}
+
+// NewCipherWithEngine ...
+func NewCipherWithEngine(engine hash.Engine, key string, rounds int) *Cipher {
 	return &Cipher{
+		Engine: engine,
 		Key:    key,
 		Rounds: rounds,
 	}
 }
+
+// NewSecretCipher returns a Cipher whose master key is the passed secret
+func NewSecretCipher(engine hash.Engine, secret *keys.Secret, rounds int) *Cipher {
+	return &Cipher{
+		Engine:   engine,
+		Rounds:   rounds,
+		Schedule: SecretKey{secret},
+	}
+}
+
+//--- utilities
+
+// orDefault returns the SHA-256 engine if none is set
+func orDefault(engine hash.Engine) hash.Engine {
+	if engine == "" {
+		return hash.SHA_256
+	}
+	return engine
+}
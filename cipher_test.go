@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/cyrildever/feistel"
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/common/utils/runes"
 	"github.com/cyrildever/feistel/exception"
 	utls "github.com/cyrildever/go-utls/common/utils"
 	"gotest.tools/assert"
@@ -31,6 +33,57 @@ func TestDecrypt(t *testing.T) {
 	assert.Equal(t, found, expected)
 }
 
+// TestCipherEngine ...
+func TestCipherEngine(t *testing.T) {
+	ref := "Edgewhere"
+	cipher := feistel.NewCipherWithEngine(hash.BLAKE2b, "8ed9dcc1701c064f0fd7ae235f15143f989920e0ee9658bb7882c8d7d5f05692", 10)
+	found, err := cipher.Encrypt(ref)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "3429530c564d0b0b165d")
+	deciphered, err := cipher.Decrypt(found)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, ref)
+
+	// SHA-256 is the default engine
+	literal := feistel.Cipher{Key: "8ed9dcc1701c064f0fd7ae235f15143f989920e0ee9658bb7882c8d7d5f05692", Rounds: 10}
+	found, err = literal.Encrypt(ref)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "3d7c0a0f51415a521054")
+
+	cipher.Engine = "md5"
+	_, err = cipher.Encrypt(ref)
+	_, ok := err.(*exception.WrongCipherParametersError)
+	assert.Assert(t, ok)
+}
+
+// TestNormalization ...
+func TestNormalization(t *testing.T) {
+	composed := "Am\u00e9lie"
+	decomposed := "Ame\u0301lie"
+
+	cipher := feistel.NewCipher("8ed9dcc1701c064f0fd7ae235f15143f989920e0ee9658bb7882c8d7d5f05692", 10)
+	cipher.Normalization = runes.NFKC
+	fromComposed, err := cipher.Encrypt(composed)
+	assert.NilError(t, err)
+	fromDecomposed, err := cipher.Encrypt(decomposed)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, fromComposed, fromDecomposed)
+	deciphered, err := cipher.Decrypt(fromDecomposed)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, composed)
+
+	custom := feistel.NewCustomCipher([]string{"1234567890abcdef", "9876543210fedcba"})
+	custom.Normalization = runes.NFD
+	fromComposed, err = custom.Encrypt(composed)
+	assert.NilError(t, err)
+	fromDecomposed, err = custom.Encrypt(decomposed)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, fromComposed, fromDecomposed)
+	deciphered, err = custom.Decrypt(fromComposed)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, decomposed)
+}
+
 // TestEmptyParameters ...
 func TestEmptyParameters(t *testing.T) {
 	// Empty key
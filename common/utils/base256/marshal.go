@@ -0,0 +1,187 @@
+package base256
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/cyrildever/feistel/exception"
+)
+
+//--- TYPES
+
+// Encoding defines the text representation of a Readable
+type Encoding string
+
+// HexReadable is a Readable whose text and JSON representation is the lowercase hexadecimal string of its bytes
+type HexReadable Readable
+
+// Base64Readable is a Readable whose text and JSON representation is the standard base64 string of its bytes
+type Base64Readable Readable
+
+const (
+	READABLE_ENCODING Encoding = "readable" // The characters of the readable charset
+	HEX_ENCODING      Encoding = "hex"      // The lowercase hexadecimal string of the underlying bytes
+	BASE64_ENCODING   Encoding = "base64"   // The standard base64 string of the underlying bytes, with padding
+)
+
+//--- METHODS
+
+// Encode returns the representation of the Readable in the passed encoding
+func (b256 Readable) Encode(encoding Encoding) (string, error) {
+	switch encoding {
+	case READABLE_ENCODING:
+		if !b256.IsValid() {
+			return "", exception.NewInvalidEncodingError()
+		}
+		return string(b256), nil
+	case HEX_ENCODING:
+		return hex.EncodeToString(b256.Bytes()), nil
+	case BASE64_ENCODING:
+		return base64.StdEncoding.EncodeToString(b256.Bytes()), nil
+	default:
+		return "", exception.NewInvalidEncodingError()
+	}
+}
+
+// IsValid returns true if all the characters belong to the readable charset
+func (b256 Readable) IsValid() bool {
+	for _, char := range b256 {
+		if IndexOf(char) == -1 {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalBinary returns the underlying bytes
+func (b256 Readable) MarshalBinary() ([]byte, error) {
+	return b256.Bytes(), nil
+}
+
+// MarshalJSON returns the JSON string of the Readable, as for any other string
+func (b256 Readable) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(b256))
+}
+
+// MarshalText returns the Readable as is
+func (b256 Readable) MarshalText() ([]byte, error) {
+	return []byte(b256), nil
+}
+
+// UnmarshalBinary ...
+func (b256 *Readable) UnmarshalBinary(data []byte) error {
+	*b256 = ToBase256Readable(data)
+	return nil
+}
+
+// UnmarshalJSON reads a JSON string in the readable charset
+func (b256 *Readable) UnmarshalJSON(data []byte) error {
+	return unmarshalJSON(b256, data, READABLE_ENCODING)
+}
+
+// UnmarshalText reads a text in the readable charset, validating its characters
+func (b256 *Readable) UnmarshalText(text []byte) error {
+	return unmarshalText(b256, text, READABLE_ENCODING)
+}
+
+// MarshalJSON returns the JSON hexadecimal string of the underlying bytes
+func (h HexReadable) MarshalJSON() ([]byte, error) {
+	return marshalJSON(Readable(h), HEX_ENCODING)
+}
+
+// MarshalText returns the hexadecimal string of the underlying bytes
+func (h HexReadable) MarshalText() ([]byte, error) {
+	return marshalText(Readable(h), HEX_ENCODING)
+}
+
+// UnmarshalJSON reads a JSON hexadecimal string
+func (h *HexReadable) UnmarshalJSON(data []byte) error {
+	return unmarshalJSON((*Readable)(h), data, HEX_ENCODING)
+}
+
+// UnmarshalText reads a hexadecimal string
+func (h *HexReadable) UnmarshalText(text []byte) error {
+	return unmarshalText((*Readable)(h), text, HEX_ENCODING)
+}
+
+// MarshalJSON returns the JSON base64 string of the underlying bytes
+func (b64 Base64Readable) MarshalJSON() ([]byte, error) {
+	return marshalJSON(Readable(b64), BASE64_ENCODING)
+}
+
+// MarshalText returns the base64 string of the underlying bytes
+func (b64 Base64Readable) MarshalText() ([]byte, error) {
+	return marshalText(Readable(b64), BASE64_ENCODING)
+}
+
+// UnmarshalJSON reads a JSON base64 string
+func (b64 *Base64Readable) UnmarshalJSON(data []byte) error {
+	return unmarshalJSON((*Readable)(b64), data, BASE64_ENCODING)
+}
+
+// UnmarshalText reads a base64 string
+func (b64 *Base64Readable) UnmarshalText(text []byte) error {
+	return unmarshalText((*Readable)(b64), text, BASE64_ENCODING)
+}
+
+//--- FUNCTIONS
+
+// Decode returns the Readable represented by the passed string in the passed encoding
+func Decode(str string, encoding Encoding) (Readable, error) {
+	var bytes []byte
+	var err error
+	switch encoding {
+	case READABLE_ENCODING:
+		b256 := Readable(str)
+		if !b256.IsValid() {
+			return "", exception.NewInvalidEncodingError()
+		}
+		return b256, nil
+	case HEX_ENCODING:
+		bytes, err = hex.DecodeString(str)
+	case BASE64_ENCODING:
+		bytes, err = base64.StdEncoding.DecodeString(str)
+	default:
+		return "", exception.NewInvalidEncodingError()
+	}
+	if err != nil {
+		return "", exception.NewInvalidEncodingError()
+	}
+	return ToBase256Readable(bytes), nil
+}
+
+//--- utilities
+
+func marshalJSON(b256 Readable, encoding Encoding) ([]byte, error) {
+	str, err := b256.Encode(encoding)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(str)
+}
+
+func marshalText(b256 Readable, encoding Encoding) ([]byte, error) {
+	str, err := b256.Encode(encoding)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(str), nil
+}
+
+func unmarshalJSON(b256 *Readable, data []byte, encoding Encoding) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return exception.NewInvalidEncodingError()
+	}
+	return unmarshalText(b256, []byte(str), encoding)
+}
+
+func unmarshalText(b256 *Readable, text []byte, encoding Encoding) error {
+	decoded, err := Decode(string(text), encoding)
+	if err != nil {
+		return err
+	}
+	*b256 = decoded
+	return nil
+}
@@ -0,0 +1,103 @@
+package base256_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cyrildever/feistel/common/utils/base256"
+	"github.com/cyrildever/feistel/exception"
+	"gotest.tools/assert"
+)
+
+// TestEncode ...
+func TestEncode(t *testing.T) {
+	b256 := base256.Readable("K¡(#q|r5*")
+	assert.Assert(t, b256.IsValid())
+	assert.Assert(t, !base256.Readable("K¡(#q|r5* ").IsValid()) // The space isn't part of the charset
+
+	for encoding, expected := range map[base256.Encoding]string{
+		base256.READABLE_ENCODING: "K¡(#q|r5*",
+		base256.HEX_ENCODING:      "2a5d07024f5a501409",
+		base256.BASE64_ENCODING:   "Kl0HAk9aUBQJ",
+	} {
+		encoded, err := b256.Encode(encoding)
+		assert.NilError(t, err)
+		assert.Equal(t, encoded, expected)
+		decoded, err := base256.Decode(encoded, encoding)
+		assert.NilError(t, err)
+		assert.Equal(t, decoded, b256)
+	}
+
+	_, err := b256.Encode("base32")
+	assert.Error(t, err, exception.NewInvalidEncodingError().Error())
+	for encoding, wrong := range map[base256.Encoding]string{
+		base256.READABLE_ENCODING: "not readable",
+		base256.HEX_ENCODING:      "2a5d0",
+		base256.BASE64_ENCODING:   "Kl0HAk9aUBQJ=",
+	} {
+		_, err := base256.Decode(wrong, encoding)
+		assert.Error(t, err, exception.NewInvalidEncodingError().Error(), encoding)
+	}
+}
+
+// TestMarshal ...
+func TestMarshal(t *testing.T) {
+	type record struct {
+		Email base256.Readable `json:"email"`
+	}
+	source := record{Email: base256.Readable("K¡(#q|r5*")}
+
+	serialized, err := json.Marshal(source)
+	assert.NilError(t, err)
+	assert.Equal(t, string(serialized), `{"email":"K¡(#q|r5*"}`)
+	var found record
+	assert.NilError(t, json.Unmarshal(serialized, &found))
+	assert.DeepEqual(t, found, source)
+	err = json.Unmarshal([]byte(`{"email":"K¡(#q|r5* "}`), &found)
+	assert.Error(t, err, exception.NewInvalidEncodingError().Error())
+
+	// Any value is marshalled like a plain string, as it always was
+	for _, str := range []string{"K¡(#q|r5* ", "not readable", "\xff"} {
+		serialized, err = json.Marshal(record{Email: base256.Readable(str)})
+		assert.NilError(t, err)
+		expected, _ := json.Marshal(struct {
+			Email string `json:"email"`
+		}{str})
+		assert.Equal(t, string(serialized), string(expected))
+	}
+
+	text, err := source.Email.MarshalText()
+	assert.NilError(t, err)
+	assert.Equal(t, string(text), "K¡(#q|r5*")
+
+	// Per-field representations
+	type encoded struct {
+		Email base256.Readable       `json:"email"`
+		Phone base256.HexReadable    `json:"phone"`
+		Notes base256.Base64Readable `json:"notes"`
+	}
+	mixed := encoded{Email: source.Email, Phone: base256.HexReadable(source.Email), Notes: base256.Base64Readable(source.Email)}
+	serialized, err = json.Marshal(mixed)
+	assert.NilError(t, err)
+	assert.Equal(t, string(serialized), `{"email":"K¡(#q|r5*","phone":"2a5d07024f5a501409","notes":"Kl0HAk9aUBQJ"}`)
+	var decoded encoded
+	assert.NilError(t, json.Unmarshal(serialized, &decoded))
+	assert.DeepEqual(t, decoded, mixed)
+	text, err = mixed.Phone.MarshalText()
+	assert.NilError(t, err)
+	assert.Equal(t, string(text), "2a5d07024f5a501409")
+	text, err = mixed.Notes.MarshalText()
+	assert.NilError(t, err)
+	assert.Equal(t, string(text), "Kl0HAk9aUBQJ")
+	for _, wrong := range []string{`{"phone":"2a5d0"}`, `{"notes":"Kl0HAk9aUBQJ="}`, `{"phone":42}`} {
+		err = json.Unmarshal([]byte(wrong), &decoded)
+		assert.Error(t, err, exception.NewInvalidEncodingError().Error(), wrong)
+	}
+
+	binary, err := source.Email.MarshalBinary()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, binary, []byte{42, 93, 7, 2, 79, 90, 80, 20, 9})
+	var b256 base256.Readable
+	assert.NilError(t, b256.UnmarshalBinary(binary))
+	assert.Equal(t, b256, source.Email)
+}
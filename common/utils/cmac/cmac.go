@@ -0,0 +1,81 @@
+package cmac
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+)
+
+const (
+	RB = 0x87 // Constant for 128-bit block ciphers - @see RFC 4493, section 2.3
+)
+
+//--- TYPES
+
+// CMAC computes the Cipher-based Message Authentication Code of RFC 4493 with a 128-bit block cipher, typically AES
+type CMAC struct {
+	block cipher.Block
+	k1    []byte
+	k2    []byte
+}
+
+//--- METHODS
+
+// Sum returns the 16-byte tag of the passed input
+func (c CMAC) Sum(input []byte) []byte {
+	size := c.block.BlockSize()
+	n := (len(input) + size - 1) / size
+	complete := n > 0 && len(input)%size == 0
+	if n == 0 {
+		n = 1
+	}
+	last := make([]byte, size)
+	if complete {
+		subtle.XORBytes(last, input[(n-1)*size:], c.k1)
+	} else {
+		rest := input[(n-1)*size:]
+		copy(last, rest)
+		last[len(rest)] = 0x80
+		subtle.XORBytes(last, last, c.k2)
+	}
+	x := make([]byte, size)
+	for i := 0; i < n-1; i++ {
+		subtle.XORBytes(x, x, input[i*size:(i+1)*size])
+		c.block.Encrypt(x, x)
+	}
+	subtle.XORBytes(x, x, last)
+	c.block.Encrypt(x, x)
+	return x
+}
+
+//--- FUNCTIONS
+
+// New generates the subkeys for the passed block cipher
+func New(block cipher.Block) *CMAC {
+	l := make([]byte, block.BlockSize())
+	block.Encrypt(l, l)
+	k1 := shift(l)
+	k2 := shift(k1)
+	return &CMAC{
+		block: block,
+		k1:    k1,
+		k2:    k2,
+	}
+}
+
+// Sum is a shortcut to compute the tag of the passed input in one call
+func Sum(block cipher.Block, input []byte) []byte {
+	return New(block).Sum(input)
+}
+
+func shift(b []byte) []byte {
+	shifted := make([]byte, len(b))
+	var carry byte
+	for i := len(b) - 1; i >= 0; i-- {
+		shifted[i] = b[i]<<1 | carry
+		carry = b[i] >> 7
+	}
+	if b[0]&0x80 != 0 {
+		shifted[len(b)-1] ^= RB
+	}
+	return shifted
+}
@@ -0,0 +1,23 @@
+package cmac_test
+
+import (
+	"crypto/aes"
+	"testing"
+
+	"github.com/cyrildever/feistel/common/utils/cmac"
+	utls "github.com/cyrildever/go-utls/common/utils"
+	"gotest.tools/assert"
+)
+
+// TestCMAC uses the AES-128 test vectors of RFC 4493, section 4
+func TestCMAC(t *testing.T) {
+	block, err := aes.NewCipher(utls.Must(utls.FromHex("2b7e151628aed2a6abf7158809cf4f3c")))
+	assert.NilError(t, err)
+	message := utls.Must(utls.FromHex("6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710"))
+	mac := cmac.New(block)
+
+	assert.Equal(t, utls.ToHex(mac.Sum(nil)), "bb1d6929e95937287fa37d129b756746")
+	assert.Equal(t, utls.ToHex(mac.Sum(message[:16])), "070a16b46b4d4144f79bdd9dd04a287c")
+	assert.Equal(t, utls.ToHex(mac.Sum(message[:40])), "dfa66747de9ae63030ca32611497c827")
+	assert.Equal(t, utls.ToHex(cmac.Sum(block, message)), "51f0bebf7e3b9d92fc49741779363cfe")
+}
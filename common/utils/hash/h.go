@@ -1,50 +1,61 @@
 package hash
 
-import (
-	"github.com/cyrildever/feistel/common/utils"
-	"github.com/cyrildever/feistel/exception"
-	keccak "github.com/ethereum/go-ethereum/crypto"
-	"golang.org/x/crypto/blake2b"
-	"golang.org/x/crypto/sha3"
-)
-
 //--- TYPES
 
-// Engine defines a 256-bits hash algorithm to use
+// Engine defines the hash algorithm to use
 type Engine string
 
 const (
-	BLAKE2b Engine = "blake-2b-256"
-	KECCAK  Engine = "keccak-256"
-	SHA_256 Engine = "sha-256"
-	SHA_3   Engine = "sha3-256"
+	BLAKE2b     Engine = "blake-2b-256"
+	BLAKE2b_512 Engine = "blake-2b-512"
+	BLAKE3      Engine = "blake3"
+	KECCAK      Engine = "keccak-256"
+	SHA_256     Engine = "sha-256"
+	SHA_3       Engine = "sha3-256"
+	SHA_512     Engine = "sha-512"
+	SHAKE256    Engine = "shake-256"
 )
 
+// IsAvailableEngine returns true if the passed engine is registered
 func IsAvailableEngine(engine Engine) bool {
-	// TODO Enrich with new engines
-	return engine == BLAKE2b || engine == KECCAK || engine == SHA_256 || engine == SHA_3
+	registry.RLock()
+	defer registry.RUnlock()
+	_, ok := registry.factories[engine]
+	return ok
 }
 
 //--- FUNCTIONS
 
+// H hashes the passed input using the passed registered engine
 func H(input []byte, using Engine) ([]byte, error) {
-	switch using {
-	case BLAKE2b:
-		hasher, err := blake2b.New256(nil)
-		if err != nil {
-			return nil, err
-		}
-		_, err = hasher.Write(input)
-		return hasher.Sum(nil), err
-	case KECCAK:
-		return keccak.Keccak256(input), nil
-	case SHA_256:
-		return utils.Hash(input)
-	case SHA_3:
-		hasher := sha3.New256()
-		_, err := hasher.Write(input)
-		return hasher.Sum(nil), err
-	default:
-		return nil, exception.NewUnkownEngineError()
+	hasher, err := New(using)
+	if err != nil {
+		return nil, err
+	}
+	_, err = hasher.Write(input)
+	return hasher.Sum(nil), err
+}
+
+// HN hashes the passed input using the passed registered engine, returning a digest of `size` bytes if the engine
+// is an extendable-output function, or its usual digest otherwise
+func HN(input []byte, using Engine, size int) ([]byte, error) {
+	hasher, err := New(using)
+	if err != nil {
+		return nil, err
+	}
+	_, err = hasher.Write(input)
+	if xof, ok := hasher.(XOF); ok {
+		return xof.SumN(nil, size), err
+	}
+	return hasher.Sum(nil), err
+}
+
+// IsXOF returns true if the passed engine is a registered extendable-output function
+func IsXOF(engine Engine) bool {
+	hasher, err := New(engine)
+	if err != nil {
+		return false
 	}
+	_, ok := hasher.(XOF)
+	return ok
 }
@@ -39,4 +39,76 @@ func TestH(t *testing.T) {
 		t.Fatal(err)
 	}
 	assert.Equal(t, sha3, utls.ToHex(found))
+
+	// Wide-output engines
+	blake2b512 := "4340061b5f82d4c15f44eacd8c6b61b756ce4bfaba72bbfb36d4c977b755023cc51f608d8f932ff3fc37074e1098b387ad8f44f27aa55ddb3a87afffca20c857"
+	found, err = hash.H(data, hash.BLAKE2b_512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, blake2b512, utls.ToHex(found))
+
+	sha512 := "d91c27323b6aecda8159aa7b4022a966a5b1553d4ab07975364f76ce7b02017a8f665c053c7cf2af0df4be7c7ae6201526433364d857fdca260ac2a891daf319"
+	found, err = hash.H(data, hash.SHA_512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, sha512, utls.ToHex(found))
+
+	// Extendable-output engines with their default size
+	blake3 := "540314a48d435ef6a2e3d3dcb3c8b351285157dd772be679f57a870a5ff918ed"
+	found, err = hash.H(data, hash.BLAKE3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, blake3, utls.ToHex(found))
+
+	shake256 := "6aadfaa696cb9647721d416cfd1141cb55c41392d5be58768876ac191cba59244d1c6c4215ea49030a62d9ba2df89fb9314d0f6da53524b8e6962ced7d132044"
+	found, err = hash.H(data, hash.SHAKE256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, shake256, utls.ToHex(found))
+}
+
+// TestHN ...
+func TestHN(t *testing.T) {
+	data := []byte("Edgewhere")
+
+	found, err := hash.HN(data, hash.SHAKE256, 100)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "6aadfaa696cb9647721d416cfd1141cb55c41392d5be58768876ac191cba59244d1c6c4215ea49030a62d9ba2df89fb9314d0f6da53524b8e6962ced7d13204462446428b9aa829a4951b15caa0efc9b2690254494045251cd9af9685504ca0998dcef88")
+
+	found, err = hash.HN(data, hash.BLAKE3, 100)
+	assert.NilError(t, err)
+	assert.Equal(t, len(found), 100)
+	assert.Equal(t, utls.ToHex(found[:32]), "540314a48d435ef6a2e3d3dcb3c8b351285157dd772be679f57a870a5ff918ed")
+
+	// Fixed-size engines ignore the requested size
+	found, err = hash.HN(data, hash.SHA_256, 100)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "c0c77f225dd222144bc4ef79dca00ab7d955f26da2b1e0f25df81f8a7e86917c")
+
+	assert.Assert(t, hash.IsXOF(hash.BLAKE3))
+	assert.Assert(t, hash.IsXOF(hash.SHAKE256))
+	assert.Assert(t, !hash.IsXOF(hash.SHA_512))
+	assert.Assert(t, !hash.IsXOF("md5"))
+}
+
+// TestHMAC ...
+func TestHMAC(t *testing.T) {
+	data := []byte("Edgewhere")
+	key := []byte("some-32-byte-long-key-to-be-safe")
+
+	found, err := hash.HMAC(data, key, hash.SHA_256)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "9b0dd32d94bc8465f057062babd3281cadce671f09a6fe6803404a9ec2514444")
+
+	// Native keyed mode of BLAKE2b
+	found, err = hash.HMAC(data, key, hash.BLAKE2b)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "067631178448e0465f4e43776717a6d1e246533d54d551806701c0bdd1a7ba3b")
+
+	_, err = hash.HMAC(data, key, "md5")
+	assert.Error(t, err, "unkown hash algorithm")
 }
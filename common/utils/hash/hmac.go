@@ -0,0 +1,43 @@
+package hash
+
+import (
+	"crypto/hmac"
+	stdhash "hash"
+
+	"github.com/cyrildever/feistel/exception"
+	"golang.org/x/crypto/blake2b"
+)
+
+//--- FUNCTIONS
+
+// HMAC computes the keyed hash of the passed input using the passed registered engine.
+//
+// NB: BLAKE2b uses its native keyed mode instead, the key being hashed first if it's longer than 64 bytes.
+func HMAC(input, key []byte, using Engine) ([]byte, error) {
+	if using == BLAKE2b || using == BLAKE2b_512 {
+		if len(key) > blake2b.Size {
+			hashed := blake2b.Sum512(key)
+			defer clear(hashed[:])
+			key = hashed[:]
+		}
+		size := blake2b.Size256
+		if using == BLAKE2b_512 {
+			size = blake2b.Size
+		}
+		hasher, err := blake2b.New(size, key)
+		if err != nil {
+			return nil, err
+		}
+		_, err = hasher.Write(input)
+		return hasher.Sum(nil), err
+	}
+	if !IsAvailableEngine(using) {
+		return nil, exception.NewUnkownEngineError()
+	}
+	mac := hmac.New(func() stdhash.Hash {
+		hasher, _ := New(using) // Already checked
+		return hasher
+	}, key)
+	_, err := mac.Write(input)
+	return mac.Sum(nil), err
+}
@@ -0,0 +1,91 @@
+package hash
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	stdhash "hash"
+	"sort"
+	"sync"
+
+	"github.com/cyrildever/feistel/exception"
+	keccak "github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+)
+
+var registry = struct {
+	sync.RWMutex
+	factories map[Engine]func() stdhash.Hash
+}{
+	factories: make(map[Engine]func() stdhash.Hash),
+}
+
+func init() {
+	Register(BLAKE2b, func() stdhash.Hash {
+		hasher, _ := blake2b.New256(nil) // Never fails without a key
+		return hasher
+	})
+	Register(BLAKE2b_512, func() stdhash.Hash {
+		hasher, _ := blake2b.New512(nil)
+		return hasher
+	})
+	Register(BLAKE3, func() stdhash.Hash {
+		return NewBLAKE3()
+	})
+	Register(KECCAK, func() stdhash.Hash {
+		return keccak.NewKeccakState()
+	})
+	Register(SHA_256, sha256.New)
+	Register(SHA_3, sha3.New256)
+	Register(SHA_512, sha512.New)
+	Register(SHAKE256, func() stdhash.Hash {
+		return NewSHAKE256()
+	})
+}
+
+//--- FUNCTIONS
+
+// Engines returns the sorted list of all registered engines
+func Engines() []Engine {
+	registry.RLock()
+	defer registry.RUnlock()
+	engines := make([]Engine, 0, len(registry.factories))
+	for engine := range registry.factories {
+		engines = append(engines, engine)
+	}
+	sort.Slice(engines, func(i, j int) bool {
+		return engines[i] < engines[j]
+	})
+	return engines
+}
+
+// New returns a new hash.Hash computing the checksum of the passed registered engine
+func New(engine Engine) (stdhash.Hash, error) {
+	registry.RLock()
+	factory, ok := registry.factories[engine]
+	registry.RUnlock()
+	if !ok {
+		return nil, exception.NewUnkownEngineError()
+	}
+	return factory(), nil
+}
+
+// Register makes a hash engine available to the ciphers through the passed factory.
+// If the returned hash.Hash implements XOF, the engine is used as an extendable-output function.
+//
+// NB: Like `database/sql.Register()`, it panics if the engine name is empty, if the factory is nil or if the engine
+// is already registered, so it should preferably be called from an `init()` function.
+func Register(engine Engine, factory func() stdhash.Hash) {
+	if engine == "" {
+		panic("hash: empty engine name")
+	}
+	if factory == nil {
+		panic("hash: nil factory for engine " + string(engine))
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if _, exists := registry.factories[engine]; exists {
+		panic("hash: Register called twice for engine " + string(engine))
+	}
+	registry.factories[engine] = factory
+}
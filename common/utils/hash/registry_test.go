@@ -0,0 +1,57 @@
+package hash_test
+
+import (
+	"crypto/sha512"
+	"testing"
+
+	"github.com/cyrildever/feistel"
+	"github.com/cyrildever/feistel/common/utils/hash"
+	utls "github.com/cyrildever/go-utls/common/utils"
+	"gotest.tools/assert"
+)
+
+const SHA_512_256 hash.Engine = "sha-512/256"
+
+func init() {
+	hash.Register(SHA_512_256, sha512.New512_256)
+}
+
+// TestRegister ...
+func TestRegister(t *testing.T) {
+	assert.Assert(t, hash.IsAvailableEngine(hash.SHA_256))
+	assert.Assert(t, hash.IsAvailableEngine(SHA_512_256))
+	assert.Assert(t, !hash.IsAvailableEngine("md5"))
+	assert.DeepEqual(t, hash.Engines(), []hash.Engine{
+		hash.BLAKE2b, hash.BLAKE2b_512, hash.BLAKE3, hash.KECCAK, hash.SHA_256, hash.SHA_512, SHA_512_256, hash.SHA_3, hash.SHAKE256,
+	})
+
+	found, err := hash.H([]byte("Edgewhere"), SHA_512_256)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "cb82b2d785722a4cde0adf1d8abcaa72d8ea367860511f86140cc7cfdd7c342b")
+
+	_, err = hash.New("md5")
+	assert.Error(t, err, "unkown hash algorithm")
+
+	// Already registered
+	assert.Assert(t, panics(func() { hash.Register(hash.SHA_256, sha512.New512_256) }))
+	assert.Assert(t, panics(func() { hash.Register("md5", nil) }))
+
+	// Custom engines are available to the FPE cipher
+	cipher := feistel.NewFPECipher(SHA_512_256, "some-32-byte-long-key-to-be-safe", 10)
+	obfuscated, err := cipher.EncryptString("Edgewhere")
+	assert.NilError(t, err)
+	deciphered, err := cipher.DecryptString(obfuscated)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, "Edgewhere")
+
+	_, err = feistel.NewFPECipher("md5", "some-32-byte-long-key-to-be-safe", 10).EncryptString("Edgewhere")
+	assert.Error(t, err, "wrong cipher parameters: keys and rounds can't be null")
+}
+
+func panics(fn func()) (panicked bool) {
+	defer func() {
+		panicked = recover() != nil
+	}()
+	fn()
+	return
+}
@@ -0,0 +1,66 @@
+package hash
+
+import (
+	stdhash "hash"
+
+	"golang.org/x/crypto/sha3"
+	"lukechampine.com/blake3"
+)
+
+//--- TYPES
+
+// XOF is a hash.Hash with an extendable output, ie. able to produce a digest of any length
+type XOF interface {
+	stdhash.Hash
+
+	// SumN appends a digest of `size` bytes of the current state to b without changing the underlying state
+	SumN(b []byte, size int) []byte
+}
+
+type shake struct {
+	sha3.ShakeHash
+	size int
+}
+
+type blake3XOF struct {
+	*blake3.Hasher
+}
+
+//--- METHODS
+
+func (s shake) Size() int {
+	return s.size
+}
+
+func (s shake) Sum(b []byte) []byte {
+	return s.SumN(b, s.size)
+}
+
+func (s shake) SumN(b []byte, size int) []byte {
+	out := make([]byte, size)
+	_, _ = s.Clone().Read(out) // Never fails
+	return append(b, out...)
+}
+
+func (b3 blake3XOF) SumN(b []byte, size int) []byte {
+	out := make([]byte, size)
+	_, _ = b3.XOF().Read(out) // Never fails below 2^64 bytes
+	return append(b, out...)
+}
+
+//--- FUNCTIONS
+
+// NewSHAKE256 returns a SHAKE256 XOF whose default output is 512 bits
+func NewSHAKE256() XOF {
+	return shake{
+		ShakeHash: sha3.NewShake256(),
+		size:      64,
+	}
+}
+
+// NewBLAKE3 returns a BLAKE3 XOF whose default output is 256 bits
+func NewBLAKE3() XOF {
+	return blake3XOF{
+		Hasher: blake3.New(32, nil),
+	}
+}
@@ -0,0 +1,41 @@
+package runes
+
+import (
+	"golang.org/x/text/unicode/norm"
+)
+
+//--- TYPES
+
+// Normalization defines the Unicode normalization form to apply to a string, if any
+type Normalization string
+
+const (
+	NONE Normalization = ""
+	NFC  Normalization = "NFC"
+	NFD  Normalization = "NFD"
+	NFKC Normalization = "NFKC"
+	NFKD Normalization = "NFKD"
+)
+
+//--- METHODS
+
+// Apply returns the passed string in the normalization form, or unchanged if no (or an unknown) form is set
+func (n Normalization) Apply(str string) string {
+	switch n {
+	case NFC:
+		return norm.NFC.String(str)
+	case NFD:
+		return norm.NFD.String(str)
+	case NFKC:
+		return norm.NFKC.String(str)
+	case NFKD:
+		return norm.NFKD.String(str)
+	default:
+		return str
+	}
+}
+
+// IsValid ...
+func (n Normalization) IsValid() bool {
+	return n == NONE || n == NFC || n == NFD || n == NFKC || n == NFKD
+}
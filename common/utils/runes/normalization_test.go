@@ -0,0 +1,25 @@
+package runes_test
+
+import (
+	"testing"
+
+	"github.com/cyrildever/feistel/common/utils/runes"
+	"gotest.tools/assert"
+)
+
+// TestNormalization ...
+func TestNormalization(t *testing.T) {
+	composed := "\u00e9"
+	decomposed := "e\u0301"
+	assert.Assert(t, composed != decomposed)
+
+	assert.Equal(t, runes.NONE.Apply(decomposed), decomposed)
+	assert.Equal(t, runes.NFC.Apply(decomposed), composed)
+	assert.Equal(t, runes.NFD.Apply(composed), decomposed)
+	assert.Equal(t, runes.NFKC.Apply("ﬁ"), "fi")
+	assert.Equal(t, runes.NFKD.Apply("ﬁé"), "fi"+decomposed)
+
+	assert.Assert(t, runes.NFC.IsValid())
+	assert.Assert(t, runes.NONE.IsValid())
+	assert.Assert(t, !runes.Normalization("nfc").IsValid())
+}
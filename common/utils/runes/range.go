@@ -0,0 +1,88 @@
+package runes
+
+import (
+	"unicode/utf8"
+)
+
+const (
+	SURROGATE_MIN rune = 0xD800
+	SURROGATE_MAX rune = 0xDFFF
+)
+
+// Predefined ranges of Unicode code points
+var (
+	ASCII   = Range{Min: 0x0000, Max: 0x007F}
+	DIGITS  = Range{Min: '0', Max: '9'}
+	LATIN_1 = Range{Min: 0x0000, Max: 0x00FF}
+	BMP     = Range{Min: 0x0000, Max: 0xFFFF}
+	UNICODE = Range{Min: 0x0000, Max: utf8.MaxRune}
+)
+
+//--- TYPES
+
+// Range defines a contiguous set of Unicode code points from `Min` to `Max` (both included).
+//
+// NB: The surrogate halves (U+D800 to U+DFFF) are never part of a range as they can't be encoded in valid UTF-8.
+type Range struct {
+	Min rune
+	Max rune
+}
+
+//--- METHODS
+
+// At returns the code point at the passed index in the range, or -1 if the index is out of bounds
+func (r Range) At(index int) rune {
+	if index < 0 || index >= r.Size() {
+		return -1
+	}
+	start := r.first()
+	char := start + rune(index)
+	if start < SURROGATE_MIN && char >= SURROGATE_MIN {
+		char += SURROGATE_MAX - SURROGATE_MIN + 1
+	}
+	return char
+}
+
+// Contains ...
+func (r Range) Contains(char rune) bool {
+	return r.IsValid() && char >= r.Min && char <= r.Max && utf8.ValidRune(char)
+}
+
+// IndexOf returns the position of the passed code point in the range, or -1 if it's not part of it
+func (r Range) IndexOf(char rune) int {
+	if !r.Contains(char) {
+		return -1
+	}
+	start := r.first()
+	index := int(char - start)
+	if start < SURROGATE_MIN && char > SURROGATE_MAX {
+		index -= int(SURROGATE_MAX - SURROGATE_MIN + 1)
+	}
+	return index
+}
+
+// IsValid ...
+func (r Range) IsValid() bool {
+	return r.Min >= 0 && r.Max <= utf8.MaxRune && r.Min <= r.Max && r.Size() > 1
+}
+
+// Size returns the number of valid code points in the range
+func (r Range) Size() int {
+	if r.Min < 0 || r.Max > utf8.MaxRune || r.Min > r.Max {
+		return 0
+	}
+	size := int(r.Max-r.Min) + 1
+	low, high := max(r.Min, SURROGATE_MIN), min(r.Max, SURROGATE_MAX)
+	if low <= high {
+		size -= int(high-low) + 1
+	}
+	return size
+}
+
+// first returns the first valid code point of the range
+func (r Range) first() rune {
+	if r.Min >= SURROGATE_MIN && r.Min <= SURROGATE_MAX {
+		return SURROGATE_MAX + 1
+	}
+	return r.Min
+}
@@ -0,0 +1,37 @@
+package runes_test
+
+import (
+	"testing"
+
+	"github.com/cyrildever/feistel/common/utils/runes"
+	"gotest.tools/assert"
+)
+
+// TestRange ...
+func TestRange(t *testing.T) {
+	assert.Equal(t, runes.ASCII.Size(), 128)
+	assert.Equal(t, runes.LATIN_1.Size(), 256)
+	assert.Equal(t, runes.BMP.Size(), 65536-2048)
+	assert.Equal(t, runes.UNICODE.Size(), 1112064)
+
+	assert.Equal(t, runes.ASCII.At(65), 'A')
+	assert.Equal(t, runes.ASCII.IndexOf('A'), 65)
+	assert.Equal(t, runes.ASCII.IndexOf('é'), -1)
+	assert.Equal(t, runes.ASCII.At(128), rune(-1))
+
+	// Surrogate halves are skipped
+	index := runes.BMP.IndexOf(0xE000)
+	assert.Equal(t, index, 0xD800)
+	assert.Equal(t, runes.BMP.At(index), rune(0xE000))
+	assert.Equal(t, runes.BMP.At(index-1), rune(0xD7FF))
+	assert.Equal(t, runes.BMP.IndexOf(0xD800), -1)
+
+	startingInSurrogates := runes.Range{Min: 0xDF00, Max: 0xE00F}
+	assert.Equal(t, startingInSurrogates.Size(), 16)
+	assert.Equal(t, startingInSurrogates.At(0), rune(0xE000))
+	assert.Equal(t, startingInSurrogates.IndexOf(0xE00F), 15)
+
+	assert.Assert(t, !runes.Range{Min: 'z', Max: 'a'}.IsValid())
+	assert.Assert(t, !runes.Range{Min: 'a', Max: 'a'}.IsValid())
+	assert.Assert(t, !runes.Range{Min: 0, Max: 0x110000}.IsValid())
+}
@@ -0,0 +1,228 @@
+package feistel
+
+import (
+	"bytes"
+	"net/url"
+	"strconv"
+
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/common/utils/runes"
+	"github.com/cyrildever/feistel/exception"
+	"github.com/cyrildever/feistel/keys"
+	"gopkg.in/yaml.v3"
+)
+
+const CONFIG_URI_SCHEME = "feistel"
+
+//--- TYPES
+
+// CipherType ...
+type CipherType string
+
+const (
+	CIPHER        CipherType = "cipher"
+	CUSTOM_CIPHER CipherType = "custom"
+	FPE_CIPHER    CipherType = "fpe"
+)
+
+// Config describes a cipher declaratively, its keys being references resolved through keys.Resolve() rather than
+// inline secrets, eg. `{type: fpe, engine: blake-2b-256, rounds: 12, key: env:FEISTEL_KEY}` in YAML or
+// `feistel://fpe?engine=sha3-256&rounds=10&key=file:/run/secrets/k` as a URI.
+//
+// The `Engine` defaults to SHA-256 and the `Version` to V1_LEGACY. The `Rounds` are ignored by the CustomCipher
+// whose `Keys` give the number of rounds. If set, the `KCV` is verified once the cipher is built.
+type Config struct {
+	Type          CipherType          `json:"type" yaml:"type"`
+	Engine        hash.Engine         `json:"engine,omitempty" yaml:"engine,omitempty"`
+	Rounds        int                 `json:"rounds,omitempty" yaml:"rounds,omitempty"`
+	Version       Version             `json:"version,omitempty" yaml:"version,omitempty"`
+	Key           string              `json:"key,omitempty" yaml:"key,omitempty"`
+	Keys          []string            `json:"keys,omitempty" yaml:"keys,omitempty"`
+	Normalization runes.Normalization `json:"normalization,omitempty" yaml:"normalization,omitempty"`
+	KCV           string              `json:"kcv,omitempty" yaml:"kcv,omitempty"`
+}
+
+//--- METHODS
+
+// URI returns the `feistel://` representation of the configuration
+func (c Config) URI() string {
+	query := url.Values{}
+	if c.Engine != "" {
+		query.Set("engine", string(c.Engine))
+	}
+	if c.Rounds != 0 {
+		query.Set("rounds", strconv.Itoa(c.Rounds))
+	}
+	if c.Version != UNVERSIONED {
+		query.Set("version", strconv.Itoa(int(c.Version)))
+	}
+	if c.Key != "" {
+		query.Set("key", c.Key)
+	}
+	for _, key := range c.Keys {
+		query.Add("keys", key)
+	}
+	if c.Normalization != runes.NONE {
+		query.Set("normalization", string(c.Normalization))
+	}
+	if c.KCV != "" {
+		query.Set("kcv", c.KCV)
+	}
+	uri := url.URL{
+		Scheme:   CONFIG_URI_SCHEME,
+		Host:     string(c.Type),
+		RawQuery: query.Encode(),
+	}
+	return uri.String()
+}
+
+// NewFromConfig builds the configured cipher after checking it against the policy, the keys being resolved
+// with the passed provider if they reference one
+func (p Policy) NewFromConfig(config Config, provider keys.KeyProvider) (cipher Obfuscator, err error) {
+	version := config.Version
+	if version == UNVERSIONED {
+		version = V1_LEGACY
+	}
+	if !version.IsValid() {
+		return nil, exception.NewUnknownVersionError()
+	}
+	engine := orDefault(config.Engine)
+	if !config.Normalization.IsValid() {
+		return nil, exception.NewWrongConfigError()
+	}
+	// The resolved secrets are only kept by the returned cipher
+	var resolved []*keys.Secret
+	defer func() {
+		if err != nil {
+			for _, secret := range resolved {
+				secret.Destroy()
+			}
+		}
+	}()
+	switch config.Type {
+	case CIPHER, FPE_CIPHER:
+		if config.Key == "" || len(config.Keys) > 0 {
+			return nil, exception.NewWrongConfigError()
+		}
+		secret, err := keys.Resolve(config.Key, provider)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, secret)
+		minRounds := p.MinCipherRounds
+		if config.Type == FPE_CIPHER {
+			minRounds = p.MinFPECipherRounds
+		}
+		if err := p.check(engine, [][]byte{secret.Bytes()}, config.Rounds, minRounds); err != nil {
+			return nil, err
+		}
+		if config.Type == CIPHER {
+			c, err := NewVersionedSecretCipher(version, engine, secret, config.Rounds)
+			if err != nil {
+				return nil, err
+			}
+			c.Normalization = config.Normalization
+			cipher = c
+		} else {
+			f, err := NewVersionedSecretFPECipher(version, engine, secret, config.Rounds)
+			if err != nil {
+				return nil, err
+			}
+			f.Normalization = config.Normalization
+			cipher = f
+		}
+	case CUSTOM_CIPHER:
+		if config.Key != "" || (config.Rounds != 0 && config.Rounds != len(config.Keys)) {
+			return nil, exception.NewWrongConfigError()
+		}
+		roundKeys := make([][]byte, len(config.Keys))
+		for i, reference := range config.Keys {
+			secret, err := keys.Resolve(reference, provider)
+			if err != nil {
+				return nil, err
+			}
+			resolved = append(resolved, secret)
+			roundKeys[i] = secret.Bytes()
+		}
+		if err := p.check(engine, roundKeys, len(roundKeys), p.MinCustomCipherRounds); err != nil {
+			return nil, err
+		}
+		if err := validateRoundKeys(roundKeys); err != nil {
+			return nil, err
+		}
+		cc, err := NewVersionedSecretCustomCipher(version, engine, resolved)
+		if err != nil {
+			return nil, err
+		}
+		cc.Normalization = config.Normalization
+		cipher = cc
+	default:
+		return nil, exception.NewWrongConfigError()
+	}
+	if config.KCV != "" {
+		if err := VerifyKCV(cipher, config.KCV); err != nil {
+			return nil, err
+		}
+	}
+	return cipher, nil
+}
+
+//--- FUNCTIONS
+
+// NewFromConfig builds the configured cipher with the basic validation of an empty policy,
+// ie. an available engine, at least two rounds and non-empty keys
+func NewFromConfig(config Config, provider keys.KeyProvider) (Obfuscator, error) {
+	return Policy{}.NewFromConfig(config, provider)
+}
+
+// ParseConfig reads a configuration in YAML or JSON, unknown fields being rejected
+func ParseConfig(data []byte) (*Config, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	var config Config
+	if err := decoder.Decode(&config); err != nil {
+		return nil, exception.NewWrongConfigError()
+	}
+	return &config, nil
+}
+
+// ParseConfigURI reads a configuration from its `feistel://` URI, eg. `feistel://custom?keys=env:K1&keys=env:K2`
+func ParseConfigURI(uri string) (*Config, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != CONFIG_URI_SCHEME || parsed.Path != "" {
+		return nil, exception.NewWrongConfigError()
+	}
+	config := Config{
+		Type: CipherType(parsed.Host),
+	}
+	for name, values := range parsed.Query() {
+		if name != "keys" && len(values) != 1 {
+			return nil, exception.NewWrongConfigError()
+		}
+		switch name {
+		case "engine":
+			config.Engine = hash.Engine(values[0])
+		case "rounds":
+			if config.Rounds, err = strconv.Atoi(values[0]); err != nil {
+				return nil, exception.NewWrongConfigError()
+			}
+		case "version":
+			version, err := strconv.Atoi(values[0])
+			if err != nil {
+				return nil, exception.NewWrongConfigError()
+			}
+			config.Version = Version(version)
+		case "key":
+			config.Key = values[0]
+		case "keys":
+			config.Keys = values
+		case "normalization":
+			config.Normalization = runes.Normalization(values[0])
+		case "kcv":
+			config.KCV = values[0]
+		default:
+			return nil, exception.NewWrongConfigError()
+		}
+	}
+	return &config, nil
+}
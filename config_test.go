@@ -0,0 +1,143 @@
+package feistel_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cyrildever/feistel"
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/common/utils/runes"
+	"github.com/cyrildever/feistel/exception"
+	"github.com/cyrildever/feistel/keys"
+	"gotest.tools/assert"
+)
+
+// TestParseConfig ...
+func TestParseConfig(t *testing.T) {
+	expected := feistel.Config{
+		Type:    feistel.FPE_CIPHER,
+		Engine:  hash.BLAKE2b,
+		Rounds:  12,
+		Version: feistel.V2,
+		Key:     "env:FEISTEL_KEY",
+	}
+	config, err := feistel.ParseConfig([]byte("{type: fpe, engine: blake-2b-256, rounds: 12, version: 2, key: env:FEISTEL_KEY}"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *config, expected)
+	config, err = feistel.ParseConfig([]byte(`{"type":"fpe","engine":"blake-2b-256","rounds":12,"version":2,"key":"env:FEISTEL_KEY"}`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *config, expected)
+
+	yaml := `type: custom
+keys:
+  - provider:k1
+  - provider:k2@3
+normalization: NFC
+`
+	config, err = feistel.ParseConfig([]byte(yaml))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *config, feistel.Config{
+		Type:          feistel.CUSTOM_CIPHER,
+		Keys:          []string{"provider:k1", "provider:k2@3"},
+		Normalization: runes.NFC,
+	})
+
+	_, err = feistel.ParseConfig([]byte("{type: fpe, round: 12}"))
+	assert.Error(t, err, exception.NewWrongConfigError().Error())
+}
+
+// TestParseConfigURI ...
+func TestParseConfigURI(t *testing.T) {
+	config, err := feistel.ParseConfigURI("feistel://fpe?engine=sha3-256&rounds=10&key=file:/run/secrets/k")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *config, feistel.Config{
+		Type:   feistel.FPE_CIPHER,
+		Engine: hash.SHA_3,
+		Rounds: 10,
+		Key:    "file:/run/secrets/k",
+	})
+
+	config = &feistel.Config{
+		Type:          feistel.CUSTOM_CIPHER,
+		Version:       feistel.V2,
+		Keys:          []string{"env:K1", "env:K2", "provider:k3@2"},
+		Normalization: runes.NFKC,
+		KCV:           "327db1cc",
+	}
+	uri := config.URI()
+	assert.Equal(t, uri, "feistel://custom?kcv=327db1cc&keys=env%3AK1&keys=env%3AK2&keys=provider%3Ak3%402&normalization=NFKC&version=2")
+	parsed, err := feistel.ParseConfigURI(uri)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *parsed, *config)
+
+	for _, wrong := range []string{
+		"https://fpe?rounds=10",
+		"feistel://fpe/path?rounds=10",
+		"feistel://fpe?rounds=ten",
+		"feistel://fpe?rounds=10&rounds=12",
+		"feistel://fpe?round=10",
+	} {
+		_, err = feistel.ParseConfigURI(wrong)
+		assert.Error(t, err, exception.NewWrongConfigError().Error(), wrong)
+	}
+}
+
+// TestNewFromConfig ...
+func TestNewFromConfig(t *testing.T) {
+	t.Setenv("FEISTEL_KEY", katKey)
+	path := filepath.Join(t.TempDir(), "k")
+	assert.NilError(t, os.WriteFile(path, []byte(katKey+"\n"), 0600))
+	provider := keys.NewMemoryProvider()
+	for i, key := range katKeys {
+		assert.NilError(t, provider.Set("k"+string(rune('1'+i)), 1, []byte(key)))
+	}
+
+	config, _ := feistel.ParseConfigURI("feistel://fpe?engine=blake-2b-256&rounds=10&key=file:" + path + "&kcv=9c55f6a0")
+	cipher, err := feistel.NewFromConfig(*config, nil)
+	assert.NilError(t, err)
+	fpe, ok := cipher.(*feistel.FPECipher)
+	assert.Assert(t, ok)
+	obfuscated, err := fpe.EncryptString(katSource)
+	assert.NilError(t, err)
+	expected, _ := feistel.NewFPECipher(hash.BLAKE2b, katKey, 10).EncryptString(katSource)
+	assert.Equal(t, obfuscated, expected)
+
+	config, _ = feistel.ParseConfig([]byte("{type: cipher, rounds: 10, version: 2, key: env:FEISTEL_KEY}"))
+	cipher, err = feistel.NewFromConfig(*config, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, cipher.(*feistel.Cipher).Version(), feistel.V2)
+	found, err := cipher.Obfuscate(katSource)
+	assert.NilError(t, err)
+	v2, _ := feistel.NewVersionedCipher(feistel.V2, hash.SHA_256, katKey, 10)
+	expectedBytes, _ := v2.Encrypt(katSource)
+	assert.DeepEqual(t, found, expectedBytes)
+
+	config, _ = feistel.ParseConfigURI("feistel://custom?keys=provider:k1@1&keys=provider:k2&keys=env:FEISTEL_KEY")
+	cipher, err = feistel.NewFromConfig(*config, provider)
+	assert.NilError(t, err)
+	assert.Equal(t, len(cipher.(*feistel.CustomCipher).Secrets), 3)
+
+	// Validation
+	config, _ = feistel.ParseConfigURI("feistel://custom?keys=provider:k1&keys=provider:k2&keys=provider:k3")
+	_, err = feistel.NewFromConfig(*config, provider)
+	assert.Error(t, err, exception.NewRelatedKeysError().Error())
+	config, _ = feistel.ParseConfigURI("feistel://fpe?rounds=10&key=" + katKey)
+	_, err = feistel.NewFromConfig(*config, nil)
+	assert.Error(t, err, exception.NewUnknownKeyReferenceError().Error())
+	config, _ = feistel.ParseConfigURI("feistel://fpe?rounds=10&key=env:FEISTEL_KEY&kcv=eaebcf70")
+	_, err = feistel.NewFromConfig(*config, nil)
+	assert.Error(t, err, exception.NewWrongKeyError().Error())
+	config, _ = feistel.ParseConfigURI("feistel://fpe?rounds=2&key=env:FEISTEL_KEY")
+	_, err = feistel.DefaultPolicy.NewFromConfig(*config, nil)
+	assert.Error(t, err, exception.NewTooFewRoundsError().Error())
+	config, _ = feistel.ParseConfigURI("feistel://fpe?rounds=10&key=env:FEISTEL_MISSING_KEY")
+	_, err = feistel.NewFromConfig(*config, nil)
+	assert.Error(t, err, exception.NewKeyNotFoundError().Error())
+	config, _ = feistel.ParseConfigURI("feistel://aes?rounds=10&key=env:FEISTEL_KEY")
+	_, err = feistel.NewFromConfig(*config, nil)
+	assert.Error(t, err, exception.NewWrongConfigError().Error())
+	config, _ = feistel.ParseConfigURI("feistel://fpe?rounds=10&key=env:FEISTEL_KEY&version=9")
+	_, err = feistel.NewFromConfig(*config, nil)
+	assert.Error(t, err, exception.NewUnknownVersionError().Error())
+}
@@ -5,25 +5,38 @@ import (
 
 	"github.com/cyrildever/feistel/common/padding"
 	"github.com/cyrildever/feistel/common/utils"
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/common/utils/runes"
 	"github.com/cyrildever/feistel/exception"
-	utls "github.com/cyrildever/go-utls/common/utils"
+	"github.com/cyrildever/feistel/keys"
 	"github.com/cyrildever/go-utls/common/xor"
 )
 
 //--- TYPES
 
-// CustomCipher uses custom keys instead of the SHA-256 hashing function to provide a new key at each round.
+// CustomCipher uses custom keys instead of the hash engine to provide a new key at each round, SHA-256 being the default engine of the round function.
 // The number of rounds is then determined by the number of keys provided.
 // NB: There must be at least two keys.
+//
+// The `Secrets` are used instead of the `Keys` if set.
+//
+// If set, the Unicode `Normalization` form is applied to the source before encryption and to the deciphered string.
+// The `Round` function, its `Expansion` and its `Mask` default to the legacy ones.
 type CustomCipher struct {
-	Keys []string
+	Keys          []string
+	Normalization runes.Normalization
+	Round         Round
+	Expansion     Expansion
+	Engine        hash.Engine
+	Mask          Mask
+	Secrets       SecretKeys
 }
 
 //--- METHODS
 
 // Encrypt ...
 func (cc CustomCipher) Encrypt(src string) (ciphered []byte, err error) {
-	if len(cc.Keys) < 2 {
+	if !cc.isValid() {
 		err = exception.NewWrongCipherParametersError()
 		return
 	}
@@ -31,7 +44,7 @@ func (cc CustomCipher) Encrypt(src string) (ciphered []byte, err error) {
 		return
 	}
 	// Apply the balanced Feistel cipher
-	data := padding.Apply([]byte(src))
+	data := padding.Apply([]byte(cc.Normalization.Apply(src)))
 	if len(data)%2 != 0 {
 		err = errors.New("invalid string length: cannot be split")
 		return
@@ -40,10 +53,12 @@ func (cc CustomCipher) Encrypt(src string) (ciphered []byte, err error) {
 	if err != nil {
 		return
 	}
+	net := cc.prepare()
+	defer net.destroy()
 	parts := []string{left, right}
-	for i := 0; i < len(cc.Keys); i++ {
+	for i := 0; i < cc.rounds(); i++ {
 		left = right
-		rnd, e := cc.round(parts[1], i)
+		rnd, e := net.round(parts[1], i)
 		if e != nil {
 			err = e
 			return
@@ -60,7 +75,7 @@ func (cc CustomCipher) Encrypt(src string) (ciphered []byte, err error) {
 
 // Decrypt ...
 func (cc CustomCipher) Decrypt(ciphered []byte) (string, error) {
-	if len(cc.Keys) < 2 {
+	if !cc.isValid() {
 		return "", exception.NewWrongCipherParametersError()
 	}
 	if len(ciphered) == 0 {
@@ -74,8 +89,10 @@ func (cc CustomCipher) Decrypt(ciphered []byte) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	for i := 0; i < len(cc.Keys); i++ {
-		rnd, err := cc.round(left, len(cc.Keys)-i-1)
+	net := cc.prepare()
+	defer net.destroy()
+	for i := 0; i < cc.rounds(); i++ {
+		rnd, err := net.round(left, cc.rounds()-i-1)
 		if err != nil {
 			return "", err
 		}
@@ -86,30 +103,53 @@ func (cc CustomCipher) Decrypt(ciphered []byte) (string, error) {
 		right = left
 		left = tmp
 	}
-	return string(padding.Unapply([]byte(left + right))), nil
+	return cc.Normalization.Apply(string(padding.Unapply([]byte(left + right)))), nil
 }
 
 // Feistel implementation
 
-// round is the function applied at each round of the obfuscation process to the right side of the Feistel cipher
-func (cc CustomCipher) round(item string, index int) (string, error) {
-	addition, err := utils.Add(item, utils.Extract(cc.Keys[index], index, len(item)))
-	if err != nil {
-		return "", err
+// prepare returns the network holding the function applied at each round of the obfuscation process to the right side of the Feistel cipher
+func (cc CustomCipher) prepare() *network {
+	return newNetwork(cc.Round, cc.Expansion, cc.Mask, orDefault(cc.Engine), cc.schedule())
+}
+
+func (cc CustomCipher) rounds() int {
+	if len(cc.Secrets) > 0 {
+		return len(cc.Secrets)
 	}
-	hashed, err := utils.Hash([]byte(addition))
-	if err != nil {
-		return "", err
+	return len(cc.Keys)
+}
+
+func (cc CustomCipher) schedule() KeySchedule {
+	if len(cc.Secrets) > 0 {
+		return cc.Secrets
 	}
-	hexHashed := utls.ToHex(hashed)
-	return utils.Extract(hexHashed, index, len(item)), nil
+	return RoundKeys(cc.Keys)
+}
+
+func (cc CustomCipher) isValid() bool {
+	return cc.rounds() >= 2 && hash.IsAvailableEngine(orDefault(cc.Engine)) && cc.Normalization.IsValid() && cc.Round.IsValid() && cc.Expansion.IsValid() && cc.Mask.IsValid()
 }
 
 //--- FUNCTIONS
 
 // NewCustomCipher ...
 func NewCustomCipher(keys []string) *CustomCipher {
+	return NewCustomCipherWithEngine(hash.SHA_256, keys)
+}
+
+// NewCustomCipherWithEngine ...
+func NewCustomCipherWithEngine(engine hash.Engine, keys []string) *CustomCipher {
+	return &CustomCipher{
+		Engine: engine,
+		Keys:   keys,
+	}
+}
+
+// NewSecretCustomCipher returns a CustomCipher using the passed secrets as keys
+func NewSecretCustomCipher(engine hash.Engine, secrets []*keys.Secret) *CustomCipher {
 	return &CustomCipher{
-		Keys: keys,
+		Engine:  engine,
+		Secrets: secrets,
 	}
 }
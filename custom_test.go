@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/cyrildever/feistel"
+	"github.com/cyrildever/feistel/common/utils/hash"
 	"github.com/cyrildever/feistel/exception"
 	utls "github.com/cyrildever/go-utls/common/utils"
 	"gotest.tools/assert"
@@ -60,3 +61,26 @@ func TestCustomCipher(t *testing.T) {
 	_, ok := err.(*exception.WrongCipherParametersError)
 	assert.Assert(t, ok)
 }
+
+// TestCustomCipherEngine ...
+func TestCustomCipherEngine(t *testing.T) {
+	ref := "Edgewhere"
+	keys := []string{
+		"1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
+		"9876543210fedcba9876543210fedcba9876543210fedcba9876543210fedcba",
+		"abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789",
+	}
+	cipher := feistel.NewCustomCipherWithEngine(hash.BLAKE2b, keys)
+	found, err := cipher.Encrypt(ref)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "420b071103534f67606e")
+	deciphered, err := cipher.Decrypt(found)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, ref)
+
+	// SHA-256 is the default engine
+	literal := feistel.CustomCipher{Keys: keys}
+	found, err = literal.Encrypt(ref)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "445951465c5a19613633")
+}
@@ -0,0 +1,125 @@
+package feistel
+
+import (
+	"encoding/binary"
+	stdhash "hash"
+	"io"
+	"strconv"
+
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/exception"
+	"github.com/cyrildever/feistel/keys"
+	utls "github.com/cyrildever/go-utls/common/utils"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	DERIVE_INFO     = "feistel/derive" // Prefix of the HKDF info, followed by the label length as a 32-bit big-endian integer and the label
+	DERIVE_KEY_SIZE = 32               // Size in bytes of the derived keys
+)
+
+//--- TYPES
+
+// Master is a node of a key hierarchy, eg. the master key of an environment, from which the keys of its children
+// (tenants, tables, columns, ...) are derived one label at a time:
+//
+//	K(child) = HKDF(Engine, ikm = K(parent), salt = none, info = DERIVE_INFO || uint32be(len(label)) || label)
+//
+// of DERIVE_KEY_SIZE bytes, so that a child key doesn't reveal anything about its parent nor its siblings.
+// The key of a derived cipher is the lowercase hexadecimal string of its node's key, the round keys of a CustomCipher
+// being those of the children labelled `round/0`, `round/1`, etc.
+type Master struct {
+	Engine hash.Engine
+	Secret *keys.Secret
+}
+
+//--- METHODS
+
+// CustomCipher returns the CustomCipher of the passed version using the keys derived for the passed number of rounds
+func (m Master) CustomCipher(version Version, rounds int) (*CustomCipher, error) {
+	if !version.IsValid() {
+		return nil, exception.NewUnknownVersionError()
+	}
+	if rounds < 2 {
+		return nil, exception.NewTooFewRoundsError()
+	}
+	secrets := make([]*keys.Secret, rounds)
+	for i := range secrets {
+		child, err := m.Derive("round/" + strconv.Itoa(i))
+		if err != nil {
+			return nil, err
+		}
+		secrets[i] = child.cipherKey()
+		child.Destroy()
+	}
+	return NewVersionedSecretCustomCipher(version, orDefault(m.Engine), secrets)
+}
+
+// Derive returns the node at the passed path of labels, each label being used as is (ie. "tenant/42" is a single label)
+func (m Master) Derive(labels ...string) (*Master, error) {
+	engine := orDefault(m.Engine)
+	if len(labels) == 0 || m.Secret.Len() == 0 || !hash.IsAvailableEngine(engine) {
+		return nil, exception.NewWrongCipherParametersError()
+	}
+	factory := func() stdhash.Hash {
+		hasher, _ := hash.New(engine) // Already checked
+		return hasher
+	}
+	key := m.Secret.Bytes()
+	for i, label := range labels {
+		if label == "" {
+			return nil, exception.NewWrongCipherParametersError()
+		}
+		info := make([]byte, len(DERIVE_INFO)+4, len(DERIVE_INFO)+4+len(label))
+		copy(info, DERIVE_INFO)
+		binary.BigEndian.PutUint32(info[len(DERIVE_INFO):], uint32(len(label)))
+		info = append(info, label...)
+		child := make([]byte, DERIVE_KEY_SIZE)
+		if _, err := io.ReadFull(hkdf.New(factory, key, nil, info), child); err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			keys.Zeroize(key)
+		}
+		key = child
+	}
+	secret := keys.NewSecret(key)
+	keys.Zeroize(key)
+	return &Master{
+		Engine: m.Engine,
+		Secret: secret,
+	}, nil
+}
+
+// Destroy wipes the node's key
+func (m Master) Destroy() {
+	m.Secret.Destroy()
+}
+
+// FPECipher returns the FPECipher of the passed version using the node's key
+func (m Master) FPECipher(version Version, rounds int) (*FPECipher, error) {
+	if !version.IsValid() {
+		return nil, exception.NewUnknownVersionError()
+	}
+	if m.Secret.Len() == 0 {
+		return nil, exception.NewWrongCipherParametersError()
+	}
+	return NewVersionedSecretFPECipher(version, orDefault(m.Engine), m.cipherKey(), rounds)
+}
+
+// cipherKey returns the hexadecimal string of the node's key as a secret
+func (m Master) cipherKey() *keys.Secret {
+	hex := []byte(utls.ToHex(m.Secret.Bytes()))
+	defer keys.Zeroize(hex)
+	return keys.NewSecret(hex)
+}
+
+//--- FUNCTIONS
+
+// NewMaster ...
+func NewMaster(engine hash.Engine, secret *keys.Secret) *Master {
+	return &Master{
+		Engine: engine,
+		Secret: secret,
+	}
+}
@@ -0,0 +1,76 @@
+package feistel_test
+
+import (
+	"testing"
+
+	"github.com/cyrildever/feistel"
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/exception"
+	"github.com/cyrildever/feistel/keys"
+	utls "github.com/cyrildever/go-utls/common/utils"
+	"gotest.tools/assert"
+)
+
+// TestDerive ...
+func TestDerive(t *testing.T) {
+	master := feistel.NewMaster(hash.SHA_256, keys.NewSecret([]byte(katKey)))
+
+	tenant, err := master.Derive("tenant/42")
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(tenant.Secret.Bytes()), "22ebf41a412a10d0bf73c0ee33e29dfc27f94315206b376254cec43b2fbd31c1")
+
+	column, err := master.Derive("tenant/42", "users.email")
+	assert.NilError(t, err)
+	columnKey := "0ebee6f1c70970f491ed90ba08508c257cbea7f298b245071d8af46a1d6ea2f1"
+	assert.Equal(t, utls.ToHex(column.Secret.Bytes()), columnKey)
+	chained, _ := tenant.Derive("users.email")
+	assert.DeepEqual(t, chained.Secret.Bytes(), column.Secret.Bytes())
+
+	other, _ := master.Derive("tenant/43", "users.email")
+	assert.Assert(t, utls.ToHex(other.Secret.Bytes()) != columnKey)
+	ambiguous, _ := master.Derive("tenant", "42", "users.email")
+	assert.Assert(t, utls.ToHex(ambiguous.Secret.Bytes()) != columnKey)
+
+	// Derived ciphers
+	fpe, err := column.FPECipher(feistel.V1_LEGACY, 10)
+	assert.NilError(t, err)
+	obfuscated, err := fpe.EncryptString(katSource)
+	assert.NilError(t, err)
+	expected, _ := feistel.NewFPECipher(hash.SHA_256, columnKey, 10).EncryptString(katSource)
+	assert.Equal(t, obfuscated, expected)
+
+	fpe, err = column.FPECipher(feistel.V2, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, fpe.Version(), feistel.V2)
+	obfuscated, err = fpe.EncryptString(katSource)
+	assert.NilError(t, err)
+	v2, _ := feistel.NewVersionedFPECipher(feistel.V2, hash.SHA_256, columnKey, 10)
+	expected, _ = v2.EncryptString(katSource)
+	assert.Equal(t, obfuscated, expected)
+
+	custom, err := column.CustomCipher(feistel.V1_LEGACY, 3)
+	assert.NilError(t, err)
+	ciphered, err := custom.Encrypt(katSource)
+	assert.NilError(t, err)
+	expectedBytes, _ := feistel.NewCustomCipher([]string{
+		"770332a0eed5b103d89d6fd116cfe8d763fe82d00176682adb51ab6e24183858",
+		"7d69157168af2d8406c370e5408b73a21fa4ffd7cde41722756f2e68db21f2eb",
+		"7790eb415f0cb55045b5b66f78b6cf49565529d221047b4efe9f4ec2334b8c9e",
+	}).Encrypt(katSource)
+	assert.DeepEqual(t, ciphered, expectedBytes)
+
+	// Errors
+	_, err = master.Derive()
+	assert.Error(t, err, exception.NewWrongCipherParametersError().Error())
+	_, err = master.Derive("tenant/42", "")
+	assert.Error(t, err, exception.NewWrongCipherParametersError().Error())
+	_, err = column.FPECipher(feistel.UNVERSIONED, 10)
+	assert.Error(t, err, exception.NewUnknownVersionError().Error())
+	_, err = column.CustomCipher(feistel.V2, 1)
+	assert.Error(t, err, exception.NewTooFewRoundsError().Error())
+	column.Destroy()
+	_, err = column.FPECipher(feistel.V2, 10)
+	assert.Error(t, err, exception.NewWrongCipherParametersError().Error())
+	_, err = column.FPECipher(feistel.UNVERSIONED, 10) // The version is checked before copying the key
+	assert.Error(t, err, exception.NewUnknownVersionError().Error())
+}
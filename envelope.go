@@ -0,0 +1,311 @@
+package feistel
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/common/utils/runes"
+	"github.com/cyrildever/feistel/exception"
+)
+
+const (
+	ENVELOPE_FORMAT = 1
+	ENVELOPE_MAGIC  = "FE"
+	ENVELOPE_PREFIX = "$feistel$"
+
+	envelopeSmallDomain byte = 1 // Flag of the small-domain mode of the FPE cipher in the binary form
+)
+
+var envelopeTypes = []CipherType{CIPHER, CUSTOM_CIPHER, FPE_CIPHER} // Their index + 1 is their binary code
+
+//--- TYPES
+
+// Envelope wraps the raw bytes of an obfuscated value with the metadata needed to decrypt it, ie. the format version,
+// the cipher type, its engine, rounds and algorithm version, its round function, expansion and mask, its Unicode
+// normalization form and small-domain mode, the ID of its key in a keyring and an optional tweak ID, the latter being
+// opaque to this library.
+//
+// Its compact binary form is:
+//
+//	"FE" | format (1 byte) | type (1 byte: 1 = cipher, 2 = custom, 3 = fpe) | version (1 byte)
+//	  | flags (1 byte: 1 = small domain) | rounds (uint16be) | len(engine) (1 byte) | engine
+//	  | len(round) (1 byte) | round | len(expansion) (1 byte) | expansion | len(mask) (1 byte) | mask
+//	  | len(normalization) (1 byte) | normalization | len(key ID) (1 byte) | key ID | len(tweak ID) (1 byte) | tweak ID
+//	  | payload
+//
+// and its text form: `$feistel$v=1$type=fpe&engine=sha-256&rounds=10&version=2&round=hmac&expansion=counter-v1&mask=raw-v1&normalization=NFC&smallDomain=true&key=2025&tweak=t1$<base64 payload>`
+// where the parameters are URL-encoded in this order, the legacy options (round, expansion, mask), the normalization,
+// the small-domain mode and the tweak being omitted if empty, and the payload is standard base64 without padding.
+type Envelope struct {
+	Type          CipherType
+	Engine        hash.Engine
+	Rounds        int
+	Version       Version
+	Round         Round
+	Expansion     Expansion
+	Mask          Mask
+	Normalization runes.Normalization
+	SmallDomain   bool
+	KeyID         string
+	TweakID       string
+	Payload       []byte
+}
+
+//--- METHODS
+
+// MarshalBinary ...
+func (e Envelope) MarshalBinary() ([]byte, error) {
+	code := typeCode(e.Type)
+	if code == 0 || e.Rounds < 0 || e.Rounds > 0xffff || e.Version < 0 || e.Version > 0xff ||
+		e.KeyID == "" || len(e.Engine) > 0xff || len(e.KeyID) > 0xff || len(e.TweakID) > 0xff ||
+		!e.Round.IsValid() || !e.Expansion.IsValid() || !e.Mask.IsValid() || !e.Normalization.IsValid() ||
+		(e.SmallDomain && e.Type != FPE_CIPHER) {
+		return nil, exception.NewInvalidEnvelopeError()
+	}
+	var flags byte
+	if e.SmallDomain {
+		flags |= envelopeSmallDomain
+	}
+	var buf bytes.Buffer
+	buf.WriteString(ENVELOPE_MAGIC)
+	buf.Write([]byte{ENVELOPE_FORMAT, code, byte(e.Version), flags})
+	buf.Write(binary.BigEndian.AppendUint16(nil, uint16(e.Rounds)))
+	for _, field := range []string{
+		string(e.Engine), string(e.Round), string(e.Expansion), string(e.Mask), string(e.Normalization), e.KeyID, e.TweakID,
+	} {
+		buf.WriteByte(byte(len(field)))
+		buf.WriteString(field)
+	}
+	buf.Write(e.Payload)
+	return buf.Bytes(), nil
+}
+
+// MarshalText ...
+func (e Envelope) MarshalText() ([]byte, error) {
+	if _, err := e.MarshalBinary(); err != nil {
+		return nil, err
+	}
+	params := []string{
+		"type=" + url.QueryEscape(string(e.Type)),
+		"engine=" + url.QueryEscape(string(e.Engine)),
+		"rounds=" + strconv.Itoa(e.Rounds),
+		"version=" + strconv.Itoa(int(e.Version)),
+	}
+	if e.Round != LEGACY_ROUND {
+		params = append(params, "round="+url.QueryEscape(string(e.Round)))
+	}
+	if e.Expansion != REPEAT_EXPANSION {
+		params = append(params, "expansion="+url.QueryEscape(string(e.Expansion)))
+	}
+	if e.Mask != HEX_MASK {
+		params = append(params, "mask="+url.QueryEscape(string(e.Mask)))
+	}
+	if e.Normalization != runes.NONE {
+		params = append(params, "normalization="+url.QueryEscape(string(e.Normalization)))
+	}
+	if e.SmallDomain {
+		params = append(params, "smallDomain=true")
+	}
+	params = append(params, "key="+url.QueryEscape(e.KeyID))
+	if e.TweakID != "" {
+		params = append(params, "tweak="+url.QueryEscape(e.TweakID))
+	}
+	text := ENVELOPE_PREFIX + "v=" + strconv.Itoa(ENVELOPE_FORMAT) + "$" + strings.Join(params, "&") + "$" +
+		base64.RawStdEncoding.EncodeToString(e.Payload)
+	return []byte(text), nil
+}
+
+// String returns the text form of the envelope, or an empty string if it's invalid
+func (e Envelope) String() string {
+	text, err := e.MarshalText()
+	if err != nil {
+		return ""
+	}
+	return string(text)
+}
+
+// UnmarshalBinary ...
+func (e *Envelope) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 || string(data[:2]) != ENVELOPE_MAGIC || data[2] != ENVELOPE_FORMAT ||
+		data[3] == 0 || int(data[3]) > len(envelopeTypes) || data[5]&^envelopeSmallDomain != 0 {
+		return exception.NewInvalidEnvelopeError()
+	}
+	envelope := Envelope{
+		Type:        envelopeTypes[data[3]-1],
+		Version:     Version(data[4]),
+		SmallDomain: data[5]&envelopeSmallDomain != 0,
+		Rounds:      int(binary.BigEndian.Uint16(data[6:8])),
+	}
+	rest := data[8:]
+	fields := make([]string, 7)
+	for i := range fields {
+		if len(rest) == 0 || len(rest) < 1+int(rest[0]) {
+			return exception.NewInvalidEnvelopeError()
+		}
+		fields[i], rest = string(rest[1:1+int(rest[0])]), rest[1+int(rest[0]):]
+	}
+	envelope.Engine = hash.Engine(fields[0])
+	envelope.Round, envelope.Expansion, envelope.Mask = Round(fields[1]), Expansion(fields[2]), Mask(fields[3])
+	envelope.Normalization = runes.Normalization(fields[4])
+	envelope.KeyID, envelope.TweakID = fields[5], fields[6]
+	envelope.Payload = bytes.Clone(rest)
+	if _, err := envelope.MarshalBinary(); err != nil {
+		return err
+	}
+	*e = envelope
+	return nil
+}
+
+// UnmarshalText ...
+func (e *Envelope) UnmarshalText(text []byte) error {
+	parts := strings.Split(strings.TrimPrefix(string(text), ENVELOPE_PREFIX), "$")
+	if !strings.HasPrefix(string(text), ENVELOPE_PREFIX) || len(parts) != 3 || parts[0] != "v="+strconv.Itoa(ENVELOPE_FORMAT) {
+		return exception.NewInvalidEnvelopeError()
+	}
+	params, err := url.ParseQuery(parts[1])
+	if err != nil {
+		return exception.NewInvalidEnvelopeError()
+	}
+	rounds, err := strconv.Atoi(params.Get("rounds"))
+	if err != nil {
+		return exception.NewInvalidEnvelopeError()
+	}
+	version, err := strconv.Atoi(params.Get("version"))
+	if err != nil {
+		return exception.NewInvalidEnvelopeError()
+	}
+	smallDomain := false
+	if params.Has("smallDomain") {
+		if smallDomain, err = strconv.ParseBool(params.Get("smallDomain")); err != nil {
+			return exception.NewInvalidEnvelopeError()
+		}
+	}
+	payload, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return exception.NewInvalidEnvelopeError()
+	}
+	envelope := Envelope{
+		Type:          CipherType(params.Get("type")),
+		Engine:        hash.Engine(params.Get("engine")),
+		Rounds:        rounds,
+		Version:       Version(version),
+		Round:         Round(params.Get("round")),
+		Expansion:     Expansion(params.Get("expansion")),
+		Mask:          Mask(params.Get("mask")),
+		Normalization: runes.Normalization(params.Get("normalization")),
+		SmallDomain:   smallDomain,
+		KeyID:         params.Get("key"),
+		TweakID:       params.Get("tweak"),
+		Payload:       payload,
+	}
+	if _, err := envelope.MarshalBinary(); err != nil {
+		return err
+	}
+	*e = envelope
+	return nil
+}
+
+// Seal encrypts the passed string with the active key in an envelope, the optional tweak ID being added to its metadata
+func (k *Keyring) Seal(src string, tweakID ...string) (*Envelope, error) {
+	keyID, cipher, err := k.activeCipher()
+	if err != nil {
+		return nil, err
+	}
+	envelope, err := NewEnvelope(keyID, cipher, src)
+	if err != nil {
+		return nil, err
+	}
+	if len(tweakID) > 0 {
+		envelope.TweakID = tweakID[0]
+	}
+	return envelope, nil
+}
+
+//--- FUNCTIONS
+
+// NewEnvelope encrypts the passed string with the passed cipher and wraps the result with its metadata
+func NewEnvelope(keyID string, cipher Obfuscator, src string) (*Envelope, error) {
+	envelope, err := describe(cipher)
+	if err != nil {
+		return nil, err
+	}
+	envelope.KeyID = keyID
+	if envelope.Payload, err = cipher.Obfuscate(src); err != nil {
+		return nil, err
+	}
+	return envelope, nil
+}
+
+// Open decrypts the envelope with the key of its ID in the passed keyring, be it active or retired,
+// after checking that the key's cipher matches the envelope's metadata
+func Open(envelope *Envelope, keyring *Keyring) (string, error) {
+	cipher, err := keyring.Cipher(envelope.KeyID)
+	if err != nil {
+		return "", err
+	}
+	expected, err := describe(cipher)
+	if err != nil {
+		return "", err
+	}
+	if expected.Type != envelope.Type || expected.Engine != envelope.Engine || expected.Rounds != envelope.Rounds ||
+		expected.Version != envelope.Version || expected.Round != envelope.Round || expected.Expansion != envelope.Expansion ||
+		expected.Mask != envelope.Mask || expected.Normalization != envelope.Normalization ||
+		expected.SmallDomain != envelope.SmallDomain {
+		return "", exception.NewInvalidEnvelopeError()
+	}
+	return cipher.Deobfuscate(envelope.Payload)
+}
+
+// ParseEnvelope reads an envelope from its binary or its text form
+func ParseEnvelope(data []byte) (*Envelope, error) {
+	var envelope Envelope
+	var err error
+	if bytes.HasPrefix(data, []byte(ENVELOPE_PREFIX)) {
+		err = envelope.UnmarshalText(data)
+	} else {
+		err = envelope.UnmarshalBinary(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &envelope, nil
+}
+
+//--- utilities
+
+// describe returns an envelope holding the metadata of the passed cipher
+func describe(cipher Obfuscator) (*Envelope, error) {
+	switch c := cipher.(type) {
+	case *Cipher:
+		return describe(*c)
+	case *CustomCipher:
+		return describe(*c)
+	case *FPECipher:
+		return describe(*c)
+	case Cipher:
+		return &Envelope{Type: CIPHER, Engine: orDefault(c.Engine), Rounds: c.Rounds, Version: c.Version(),
+			Round: c.Round, Expansion: c.Expansion, Mask: c.Mask, Normalization: c.Normalization}, nil
+	case CustomCipher:
+		return &Envelope{Type: CUSTOM_CIPHER, Engine: orDefault(c.Engine), Rounds: c.rounds(), Version: c.Version(),
+			Round: c.Round, Expansion: c.Expansion, Mask: c.Mask, Normalization: c.Normalization}, nil
+	case FPECipher:
+		return &Envelope{Type: FPE_CIPHER, Engine: c.Engine, Rounds: c.Rounds, Version: c.Version(),
+			Round: c.Round, Expansion: c.Expansion, Mask: c.Mask, Normalization: c.Normalization, SmallDomain: c.SmallDomain}, nil
+	default:
+		return nil, exception.NewWrongCipherParametersError()
+	}
+}
+
+func typeCode(t CipherType) byte {
+	for i, envelopeType := range envelopeTypes {
+		if t == envelopeType {
+			return byte(i + 1)
+		}
+	}
+	return 0
+}
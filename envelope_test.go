@@ -0,0 +1,135 @@
+package feistel_test
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/cyrildever/feistel"
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/common/utils/runes"
+	"github.com/cyrildever/feistel/exception"
+	utls "github.com/cyrildever/go-utls/common/utils"
+	"gotest.tools/assert"
+)
+
+// TestEnvelope ...
+func TestEnvelope(t *testing.T) {
+	keyring, err := feistel.NewKeyring("2025", feistel.NewCipher(katKey, 10))
+	assert.NilError(t, err)
+
+	envelope, err := keyring.Seal(katSource)
+	assert.NilError(t, err)
+	assert.Equal(t, envelope.Type, feistel.CIPHER)
+	assert.Equal(t, envelope.Engine, hash.SHA_256)
+	assert.Equal(t, envelope.Rounds, 10)
+	assert.Equal(t, envelope.Version, feistel.V1_LEGACY)
+	assert.Equal(t, envelope.KeyID, "2025")
+	assert.Equal(t, utls.ToHex(envelope.Payload), "3d7c0a0f51415a521054")
+
+	binary, err := envelope.MarshalBinary()
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(binary), "4645"+"01"+"01"+"01"+"00"+"000a"+"07"+utls.ToHex([]byte("sha-256"))+"00"+"00"+"00"+"00"+"04"+utls.ToHex([]byte("2025"))+"00"+"3d7c0a0f51415a521054")
+	assert.Equal(t, envelope.String(), "$feistel$v=1$type=cipher&engine=sha-256&rounds=10&version=1&key=2025$PXwKD1FBWlIQVA")
+
+	// Rotation
+	fpe, _ := feistel.NewVersionedFPECipher(feistel.V2, hash.BLAKE2b, katShortKey, 12)
+	assert.NilError(t, keyring.Rotate("2026 & more", fpe))
+	sealed, err := keyring.Seal(katSource, "users.email")
+	assert.NilError(t, err)
+	assert.Equal(t, sealed.Type, feistel.FPE_CIPHER)
+	assert.Equal(t, sealed.Version, feistel.V2)
+	assert.Equal(t, sealed.TweakID, "users.email")
+
+	// Normalization and small-domain mode
+	small, _ := feistel.NewVersionedFPECipher(feistel.V2, hash.SHA_256, katKey, 10)
+	small.Normalization = runes.NFC
+	small.SmallDomain = true
+	assert.NilError(t, keyring.Add("small", small))
+	short, err := feistel.NewEnvelope("small", small, "Ze\u0301")
+	assert.NilError(t, err)
+	assert.Equal(t, short.Normalization, runes.NFC)
+	assert.Assert(t, short.SmallDomain)
+	assert.Assert(t, strings.HasPrefix(short.String(), "$feistel$v=1$type=fpe&engine=sha-256&rounds=10&version=2&round=hmac&expansion=counter-v1&mask=raw-v1&normalization=NFC&smallDomain=true&key=small$"))
+
+	// Unversioned options
+	aes := feistel.NewCipher(katKey, 10)
+	aes.Round = feistel.AES_ROUND
+	assert.NilError(t, keyring.Add("aes", aes))
+	unversioned, err := feistel.NewEnvelope("aes", aes, katSource)
+	assert.NilError(t, err)
+	assert.Equal(t, unversioned.Version, feistel.UNVERSIONED)
+	assert.Equal(t, unversioned.Round, feistel.AES_ROUND)
+	assert.Equal(t, unversioned.String(), "$feistel$v=1$type=cipher&engine=sha-256&rounds=10&version=0&round=aes-cmac&key=aes$"+
+		base64.RawStdEncoding.EncodeToString(unversioned.Payload))
+
+	for e, source := range map[*feistel.Envelope]string{envelope: katSource, sealed: katSource, short: "Z\u00e9", unversioned: katSource} {
+		binary, _ := e.MarshalBinary()
+		text, _ := e.MarshalText()
+		for _, data := range [][]byte{binary, text} {
+			parsed, err := feistel.ParseEnvelope(data)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, *parsed, *e)
+			deciphered, err := feistel.Open(parsed, keyring)
+			assert.NilError(t, err)
+			assert.Equal(t, deciphered, source)
+		}
+	}
+
+	// Mismatching or invalid envelopes
+	tampered := *envelope
+	tampered.Rounds = 12
+	_, err = feistel.Open(&tampered, keyring)
+	assert.Error(t, err, exception.NewInvalidEnvelopeError().Error())
+	tampered = *short
+	tampered.SmallDomain = false
+	_, err = feistel.Open(&tampered, keyring)
+	assert.Error(t, err, exception.NewInvalidEnvelopeError().Error())
+	tampered = *short
+	tampered.Normalization = runes.NFD
+	_, err = feistel.Open(&tampered, keyring)
+	assert.Error(t, err, exception.NewInvalidEnvelopeError().Error())
+	tampered = *unversioned
+	tampered.Round = feistel.HMAC_ROUND
+	_, err = feistel.Open(&tampered, keyring)
+	assert.Error(t, err, exception.NewInvalidEnvelopeError().Error())
+	tampered = *unversioned
+	tampered.Expansion = feistel.COUNTER_EXPANSION_V1
+	_, err = feistel.Open(&tampered, keyring)
+	assert.Error(t, err, exception.NewInvalidEnvelopeError().Error())
+	tampered = *unversioned
+	tampered.Mask = feistel.RAW_MASK_V1
+	_, err = feistel.Open(&tampered, keyring)
+	assert.Error(t, err, exception.NewInvalidEnvelopeError().Error())
+	tampered = *envelope
+	tampered.KeyID = "2024"
+	_, err = feistel.Open(&tampered, keyring)
+	assert.Error(t, err, exception.NewUnknownKeyIDError().Error())
+
+	for _, wrong := range []string{
+		"",
+		"FE\x02\x01\x01\x00\x00\x0a\x00\x00\x00\x00\x00\x042025\x00",
+		"FE\x01\x04\x01\x00\x00\x0a\x00\x00\x00\x00\x00\x042025\x00",
+		"FE\x01\x01\x01\x00\x00\x0a\x00\x00\x00\x00\x00\x00\x00",
+		"FE\x01\x01\x01\x00\x00\x0a\x00\x00\x00\x00\x00\x042025",
+		"FE\x01\x01\x01\x01\x00\x0a\x00\x00\x00\x00\x00\x042025\x00",
+		"FE\x01\x03\x02\x02\x00\x0a\x00\x00\x00\x00\x00\x042025\x00",
+		"FE\x01\x01\x01\x00\x00\x0a\x00\x03aes\x00\x00\x00\x042025\x00",
+		"FE\x01\x01\x01\x00\x00\x0a\x00\x00\x00\x00\x03NFX\x042025\x00",
+		"$feistel$v=2$type=cipher&engine=sha-256&rounds=10&version=1&key=2025$PXwKD1FBWlIQVA",
+		"$feistel$v=1$type=aes&engine=sha-256&rounds=10&version=1&key=2025$PXwKD1FBWlIQVA",
+		"$feistel$v=1$type=cipher&engine=sha-256&rounds=ten&version=1&key=2025$PXwKD1FBWlIQVA",
+		"$feistel$v=1$type=cipher&engine=sha-256&rounds=10&version=1&smallDomain=true&key=2025$PXwKD1FBWlIQVA",
+		"$feistel$v=1$type=fpe&engine=sha-256&rounds=10&version=2&smallDomain=maybe&key=2025$PXwKD1FBWlIQVA",
+		"$feistel$v=1$type=cipher&engine=sha-256&rounds=10&version=1&normalization=NFX&key=2025$PXwKD1FBWlIQVA",
+		"$feistel$v=1$type=cipher&engine=sha-256&rounds=10&version=0&round=aes&key=2025$PXwKD1FBWlIQVA",
+		"$feistel$v=1$type=cipher&engine=sha-256&rounds=10&version=0&expansion=repeat&key=2025$PXwKD1FBWlIQVA",
+		"$feistel$v=1$type=cipher&engine=sha-256&rounds=10&version=0&mask=hex&key=2025$PXwKD1FBWlIQVA",
+		"$feistel$v=1$type=cipher&engine=sha-256&rounds=10&version=1&key=2025$PXwKD1FBWlIQVA==",
+	} {
+		_, err = feistel.ParseEnvelope([]byte(wrong))
+		assert.Error(t, err, exception.NewInvalidEnvelopeError().Error(), wrong)
+	}
+	_, err = feistel.NewEnvelope("2025", nil, katSource)
+	assert.Error(t, err, exception.NewWrongCipherParametersError().Error())
+}
@@ -1,5 +1,165 @@
 package exception
 
+// ActiveKeyRemovalError ...
+type ActiveKeyRemovalError struct {
+	message string
+}
+
+func (e *ActiveKeyRemovalError) Error() string {
+	return e.message
+}
+
+// NewActiveKeyRemovalError ...
+func NewActiveKeyRemovalError() *ActiveKeyRemovalError {
+	return &ActiveKeyRemovalError{
+		message: "the active key cannot be removed: rotate it first",
+	}
+}
+
+// DuplicateColumnError ...
+type DuplicateColumnError struct {
+	message string
+}
+
+func (e *DuplicateColumnError) Error() string {
+	return e.message
+}
+
+// NewDuplicateColumnError ...
+func NewDuplicateColumnError() *DuplicateColumnError {
+	return &DuplicateColumnError{
+		message: "column already registered",
+	}
+}
+
+// DuplicateKeyIDError ...
+type DuplicateKeyIDError struct {
+	message string
+}
+
+func (e *DuplicateKeyIDError) Error() string {
+	return e.message
+}
+
+// NewDuplicateKeyIDError ...
+func NewDuplicateKeyIDError() *DuplicateKeyIDError {
+	return &DuplicateKeyIDError{
+		message: "duplicate key ID",
+	}
+}
+
+// ForbiddenEngineError ...
+type ForbiddenEngineError struct {
+	message string
+}
+
+func (e *ForbiddenEngineError) Error() string {
+	return e.message
+}
+
+// NewForbiddenEngineError ...
+func NewForbiddenEngineError() *ForbiddenEngineError {
+	return &ForbiddenEngineError{
+		message: "forbidden hash engine",
+	}
+}
+
+// InvalidEncodingError ...
+type InvalidEncodingError struct {
+	message string
+}
+
+func (e *InvalidEncodingError) Error() string {
+	return e.message
+}
+
+// NewInvalidEncodingError ...
+func NewInvalidEncodingError() *InvalidEncodingError {
+	return &InvalidEncodingError{
+		message: "invalid or unknown encoding",
+	}
+}
+
+// InvalidEnvelopeError ...
+type InvalidEnvelopeError struct {
+	message string
+}
+
+func (e *InvalidEnvelopeError) Error() string {
+	return e.message
+}
+
+// NewInvalidEnvelopeError ...
+func NewInvalidEnvelopeError() *InvalidEnvelopeError {
+	return &InvalidEnvelopeError{
+		message: "invalid envelope or not matching the cipher",
+	}
+}
+
+// InvalidTagError ...
+type InvalidTagError struct {
+	message string
+}
+
+func (e *InvalidTagError) Error() string {
+	return e.message
+}
+
+// NewInvalidTagError ...
+func NewInvalidTagError() *InvalidTagError {
+	return &InvalidTagError{
+		message: "invalid feistel struct tag or tagged field type",
+	}
+}
+
+// InvalidTargetError ...
+type InvalidTargetError struct {
+	message string
+}
+
+func (e *InvalidTargetError) Error() string {
+	return e.message
+}
+
+// NewInvalidTargetError ...
+func NewInvalidTargetError() *InvalidTargetError {
+	return &InvalidTargetError{
+		message: "invalid target: must be a non-nil pointer",
+	}
+}
+
+// KeyNotFoundError ...
+type KeyNotFoundError struct {
+	message string
+}
+
+func (e *KeyNotFoundError) Error() string {
+	return e.message
+}
+
+// NewKeyNotFoundError ...
+func NewKeyNotFoundError() *KeyNotFoundError {
+	return &KeyNotFoundError{
+		message: "key not found",
+	}
+}
+
+// NoActiveKeyError ...
+type NoActiveKeyError struct {
+	message string
+}
+
+func (e *NoActiveKeyError) Error() string {
+	return e.message
+}
+
+// NewNoActiveKeyError ...
+func NewNoActiveKeyError() *NoActiveKeyError {
+	return &NoActiveKeyError{
+		message: "no active key: use Rotate() to set one",
+	}
+}
+
 // NotUint64Error ...
 type NotUint64Error struct {
 	message string
@@ -16,6 +176,86 @@ func NewNotUint64Error() *NotUint64Error {
 	}
 }
 
+// OutOfRangeError ...
+type OutOfRangeError struct {
+	message string
+}
+
+func (e *OutOfRangeError) Error() string {
+	return e.message
+}
+
+// NewOutOfRangeError ...
+func NewOutOfRangeError() *OutOfRangeError {
+	return &OutOfRangeError{
+		message: "character out of the Unicode range",
+	}
+}
+
+// RelatedKeysError ...
+type RelatedKeysError struct {
+	message string
+}
+
+func (e *RelatedKeysError) Error() string {
+	return e.message
+}
+
+// NewRelatedKeysError ...
+func NewRelatedKeysError() *RelatedKeysError {
+	return &RelatedKeysError{
+		message: "duplicate or trivially related round keys",
+	}
+}
+
+// RetiredKeyError ...
+type RetiredKeyError struct {
+	message string
+}
+
+func (e *RetiredKeyError) Error() string {
+	return e.message
+}
+
+// NewRetiredKeyError ...
+func NewRetiredKeyError() *RetiredKeyError {
+	return &RetiredKeyError{
+		message: "retired key: only usable for decryption",
+	}
+}
+
+// TooFewRoundsError ...
+type TooFewRoundsError struct {
+	message string
+}
+
+func (e *TooFewRoundsError) Error() string {
+	return e.message
+}
+
+// NewTooFewRoundsError ...
+func NewTooFewRoundsError() *TooFewRoundsError {
+	return &TooFewRoundsError{
+		message: "too few rounds",
+	}
+}
+
+// TooShortToEncryptError ...
+type TooShortToEncryptError struct {
+	message string
+}
+
+func (e *TooShortToEncryptError) Error() string {
+	return e.message
+}
+
+// NewTooShortToEncryptError ...
+func NewTooShortToEncryptError() *TooShortToEncryptError {
+	return &TooShortToEncryptError{
+		message: "too short to be encrypted: use the small-domain mode",
+	}
+}
+
 // TooSmallToPreserveLengthError ...
 type TooSmallToPreserveLengthError struct {
 	message string
@@ -32,6 +272,70 @@ func NewTooSmallToPreserveLengthError() *TooSmallToPreserveLengthError {
 	}
 }
 
+// UnknownColumnError ...
+type UnknownColumnError struct {
+	message string
+}
+
+func (e *UnknownColumnError) Error() string {
+	return e.message
+}
+
+// NewUnknownColumnError ...
+func NewUnknownColumnError() *UnknownColumnError {
+	return &UnknownColumnError{
+		message: "no cipher bound to the column",
+	}
+}
+
+// UnknownKeyIDError ...
+type UnknownKeyIDError struct {
+	message string
+}
+
+func (e *UnknownKeyIDError) Error() string {
+	return e.message
+}
+
+// NewUnknownKeyIDError ...
+func NewUnknownKeyIDError() *UnknownKeyIDError {
+	return &UnknownKeyIDError{
+		message: "unknown key ID",
+	}
+}
+
+// UnknownKeyReferenceError ...
+type UnknownKeyReferenceError struct {
+	message string
+}
+
+func (e *UnknownKeyReferenceError) Error() string {
+	return e.message
+}
+
+// NewUnknownKeyReferenceError ...
+func NewUnknownKeyReferenceError() *UnknownKeyReferenceError {
+	return &UnknownKeyReferenceError{
+		message: "unknown key reference: use env:, file: or provider:",
+	}
+}
+
+// UnknownVersionError ...
+type UnknownVersionError struct {
+	message string
+}
+
+func (e *UnknownVersionError) Error() string {
+	return e.message
+}
+
+// NewUnknownVersionError ...
+func NewUnknownVersionError() *UnknownVersionError {
+	return &UnknownVersionError{
+		message: "unknown algorithm version",
+	}
+}
+
 // UnkownEngineError ...
 type UnkownEngineError struct {
 	message string
@@ -48,6 +352,38 @@ func NewUnkownEngineError() *UnkownEngineError {
 	}
 }
 
+// UnsafePermissionsError ...
+type UnsafePermissionsError struct {
+	message string
+}
+
+func (e *UnsafePermissionsError) Error() string {
+	return e.message
+}
+
+// NewUnsafePermissionsError ...
+func NewUnsafePermissionsError() *UnsafePermissionsError {
+	return &UnsafePermissionsError{
+		message: "unsafe file permissions: the file must only be accessible to its owner",
+	}
+}
+
+// WeakKeyError ...
+type WeakKeyError struct {
+	message string
+}
+
+func (e *WeakKeyError) Error() string {
+	return e.message
+}
+
+// NewWeakKeyError ...
+func NewWeakKeyError() *WeakKeyError {
+	return &WeakKeyError{
+		message: "weak key: too short or not random enough",
+	}
+}
+
 // WrongCipherParametersError ...
 type WrongCipherParametersError struct {
 	message string
@@ -63,3 +399,67 @@ func NewWrongCipherParametersError() *WrongCipherParametersError {
 		message: "wrong cipher parameters: keys and rounds can't be null",
 	}
 }
+
+// WrongConfigError ...
+type WrongConfigError struct {
+	message string
+}
+
+func (e *WrongConfigError) Error() string {
+	return e.message
+}
+
+// NewWrongConfigError ...
+func NewWrongConfigError() *WrongConfigError {
+	return &WrongConfigError{
+		message: "wrong cipher configuration",
+	}
+}
+
+// WrongKDFParametersError ...
+type WrongKDFParametersError struct {
+	message string
+}
+
+func (e *WrongKDFParametersError) Error() string {
+	return e.message
+}
+
+// NewWrongKDFParametersError ...
+func NewWrongKDFParametersError() *WrongKDFParametersError {
+	return &WrongKDFParametersError{
+		message: "wrong key derivation parameters",
+	}
+}
+
+// WrongKeyError ...
+type WrongKeyError struct {
+	message string
+}
+
+func (e *WrongKeyError) Error() string {
+	return e.message
+}
+
+// NewWrongKeyError ...
+func NewWrongKeyError() *WrongKeyError {
+	return &WrongKeyError{
+		message: "key check value mismatch: wrong key or cipher parameters",
+	}
+}
+
+// WrongPassphraseError ...
+type WrongPassphraseError struct {
+	message string
+}
+
+func (e *WrongPassphraseError) Error() string {
+	return e.message
+}
+
+// NewWrongPassphraseError ...
+func NewWrongPassphraseError() *WrongPassphraseError {
+	return &WrongPassphraseError{
+		message: "wrong passphrase or corrupted keystore",
+	}
+}
@@ -3,84 +3,96 @@ package feistel
 import (
 	"encoding/binary"
 	"math/bits"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/cyrildever/feistel/common/utils"
 	"github.com/cyrildever/feistel/common/utils/base256"
 	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/common/utils/runes"
 	"github.com/cyrildever/feistel/exception"
-	utls "github.com/cyrildever/go-utls/common/utils"
+	"github.com/cyrildever/feistel/keys"
 	"github.com/cyrildever/go-utls/common/xor"
 )
 
+const (
+	SMALL_DOMAIN_MAX_LENGTH = 2 // Maximum number of bytes (or runes) handled by the small-domain mode
+)
+
 //--- TYPES
 
 // FPECipher builds a format-preserving encrypted cipher using the key with the passed hash engine at each round.
 // NB: There must be at least 2 rounds.
+//
+// When `SmallDomain` is set, inputs of at most SMALL_DOMAIN_MAX_LENGTH bytes (or runes) are permuted as a whole
+// using a bit-level Feistel network with cycle walking. Otherwise, single-byte (or single-rune) inputs that
+// couldn't be modified by the cipher are rejected with a TooShortToEncryptError, while single-byte ciphertexts
+// are still deciphered by the legacy network.
+//
+// If set, the Unicode `Normalization` form is applied to the source string before encryption and to the deciphered string.
+// The `Round` function, its `Expansion` and its `Mask` default to the legacy ones.
+// The `Schedule` of the round keys defaults to the legacy MasterKey built from the `Key`.
 type FPECipher struct {
-	Engine hash.Engine
-	Key    string
-	Rounds int
+	Engine        hash.Engine
+	Key           string
+	Rounds        int
+	SmallDomain   bool
+	Normalization runes.Normalization
+	Round         Round
+	Expansion     Expansion
+	Mask          Mask
+	Schedule      KeySchedule
 }
 
 //--- METHODS
 
 // Encrypt ...
 func (f FPECipher) Encrypt(src string) (ciphered base256.Readable, err error) {
-	if len(f.Key) == 0 || f.Rounds < 2 || !hash.IsAvailableEngine(f.Engine) {
+	if !f.isValid() {
 		err = exception.NewWrongCipherParametersError()
 		return
 	}
+	src = f.Normalization.Apply(src)
 	if len(src) == 0 {
 		return
 	}
-	// Apply the FPE Feistel cipher
-	left, right, err := utils.Split(src)
-	if err != nil {
-		return
-	}
-	parts := []string{left, right}
-	for i := 0; i < f.Rounds; i++ {
-		left = right
-		if len(parts[1]) < len(parts[0]) {
-			neutral := xor.Neutral("0")
-			parts[1] += string(neutral)
-		}
-		rnd, e := f.round(parts[1], i)
+	if f.SmallDomain && len(src) <= SMALL_DOMAIN_MAX_LENGTH {
+		net := f.prepare()
+		defer net.destroy()
+		permuted, e := f.permuteBytes(net, []byte(src), false)
 		if e != nil {
 			err = e
 			return
 		}
-		tmp := parts[0]
-		crop := false
-		if len(tmp)+1 == len(rnd) {
-			neutral := xor.Neutral(rnd[len(tmp):])
-			tmp += string(neutral)
-			crop = true
-		}
-		right, err = xor.String(tmp, rnd)
-		if err != nil {
-			return
-		}
-		if crop {
-			right = right[:len(right)-1]
-		}
-		parts = []string{left, right}
+		ciphered = base256.ToBase256Readable(permuted)
+		return
 	}
-	ciphered = base256.ToBase256Readable([]byte(parts[0] + parts[1]))
-	return
+	if len(src) == 1 {
+		err = exception.NewTooShortToEncryptError()
+		return
+	}
+	return f.encryptBytes(src)
 }
 
 // EncryptNumber ...
 func (f FPECipher) EncryptNumber(src uint64) (ciphered base256.Readable, err error) {
-	if len(f.Key) == 0 || f.Rounds < 2 || !hash.IsAvailableEngine(f.Engine) {
+	if !f.isValid() {
 		err = exception.NewWrongCipherParametersError()
 		return
 	}
+	// Numbers are binary data that mustn't be normalized
+	f.Normalization = runes.NONE
 
 	if src < 256 {
 		bytes := make([]byte, 1)
 		bytes = append(bytes, uint64ToBytes(src)...)
-		res, e := f.Encrypt(string(bytes))
+		var res base256.Readable
+		var e error
+		if len(bytes) == 1 && !f.SmallDomain {
+			res, e = f.encryptBytes(string(bytes)) // Zero is still encrypted on one byte, as it always was
+		} else {
+			res, e = f.Encrypt(string(bytes))
+		}
 		if e != nil {
 			err = e
 			return
@@ -96,6 +108,28 @@ func (f FPECipher) EncryptNumber(src uint64) (ciphered base256.Readable, err err
 	return f.Encrypt(str)
 }
 
+// EncryptRunes applies the FPE Feistel cipher at the code point level, ie. each character of the passed string is
+// replaced by another character within the passed Unicode range so that both the source and the result are always
+// valid UTF-8 strings with the same number of runes.
+//
+// NB: All the characters of the source must belong to the passed range.
+func (f FPECipher) EncryptRunes(src string, within runes.Range) (ciphered string, err error) {
+	if !f.isValid() || !within.IsValid() {
+		err = exception.NewWrongCipherParametersError()
+		return
+	}
+	digits, err := toDigits(f.Normalization.Apply(src), within)
+	if err != nil || len(digits) == 0 {
+		return
+	}
+	permuted, err := f.encryptDigits(digits, within.Size())
+	if err != nil {
+		return
+	}
+	ciphered = fromDigits(permuted, within)
+	return
+}
+
 // EncryptString ...
 func (f FPECipher) EncryptString(src string) (ciphered base256.Readable, err error) {
 	return f.Encrypt(src)
@@ -103,12 +137,21 @@ func (f FPECipher) EncryptString(src string) (ciphered base256.Readable, err err
 
 // Decrypt ...
 func (f FPECipher) Decrypt(ciphered base256.Readable) (string, error) {
-	if len(f.Key) == 0 || f.Rounds < 2 || !hash.IsAvailableEngine(f.Engine) {
+	if !f.isValid() {
 		return "", exception.NewWrongCipherParametersError()
 	}
 	if ciphered.IsEmpty() {
 		return "", nil
 	}
+	if f.SmallDomain && ciphered.Len() <= SMALL_DOMAIN_MAX_LENGTH {
+		net := f.prepare()
+		defer net.destroy()
+		permuted, err := f.permuteBytes(net, ciphered.Bytes(), true)
+		if err != nil {
+			return "", err
+		}
+		return f.Normalization.Apply(string(permuted)), nil
+	}
 	// Apply the FPE Feistel cipher
 	left, right, err := utils.Split(string(ciphered.Bytes()))
 	if err != nil {
@@ -119,13 +162,15 @@ func (f FPECipher) Decrypt(ciphered base256.Readable) (string, error) {
 		left += right[:1]
 		right = right[1:]
 	}
+	net := f.prepare()
+	defer net.destroy()
 	for i := 0; i < f.Rounds; i++ {
 		leftRound := left
 		if len(left) < len(right) {
 			neutral := xor.Neutral("0")
 			leftRound += string(neutral)
 		}
-		rnd, err := f.round(leftRound, f.Rounds-i-1)
+		rnd, err := net.round(leftRound, f.Rounds-i-1)
 		if err != nil {
 			return "", err
 		}
@@ -145,11 +190,12 @@ func (f FPECipher) Decrypt(ciphered base256.Readable) (string, error) {
 		}
 		left = tmp
 	}
-	return string([]byte(left + right)), nil
+	return f.Normalization.Apply(string([]byte(left + right))), nil
 }
 
 // DecryptNumber ...
 func (f FPECipher) DecryptNumber(ciphered base256.Readable) (uint64, error) {
+	f.Normalization = runes.NONE
 	deciphered, err := f.Decrypt(ciphered)
 	if err != nil {
 		return 0, err
@@ -157,6 +203,22 @@ func (f FPECipher) DecryptNumber(ciphered base256.Readable) (uint64, error) {
 	return bytesToUint64([]byte(deciphered))
 }
 
+// DecryptRunes reverses the EncryptRunes() method using the same Unicode range
+func (f FPECipher) DecryptRunes(ciphered string, within runes.Range) (string, error) {
+	if !f.isValid() || !within.IsValid() {
+		return "", exception.NewWrongCipherParametersError()
+	}
+	digits, err := toDigits(ciphered, within)
+	if err != nil || len(digits) == 0 {
+		return "", err
+	}
+	permuted, err := f.decryptDigits(digits, within.Size())
+	if err != nil {
+		return "", err
+	}
+	return f.Normalization.Apply(fromDigits(permuted, within)), nil
+}
+
 // DecryptString ...
 func (f FPECipher) DecryptString(ciphered base256.Readable) (string, error) {
 	return f.Decrypt(ciphered)
@@ -164,18 +226,226 @@ func (f FPECipher) DecryptString(ciphered base256.Readable) (string, error) {
 
 // Feistel implementation
 
-// round is the function applied at each round of the obfuscation process to the right side of the Feistel cipher
-func (f FPECipher) round(item string, index int) (string, error) {
-	addition, err := utils.Add(item, utils.Extract(f.Key, index, len(item)))
+// prepare returns the network holding the function applied at each round of the obfuscation process to the right side of the Feistel cipher
+func (f FPECipher) prepare() *network {
+	return newNetwork(f.Round, f.Expansion, f.Mask, f.Engine, f.schedule())
+}
+
+func (f FPECipher) schedule() KeySchedule {
+	if f.Schedule != nil {
+		return f.Schedule
+	}
+	return MasterKey(f.Key)
+}
+
+func (f FPECipher) isValid() bool {
+	return (len(f.Key) > 0 || f.Schedule != nil) && f.Rounds >= 2 && hash.IsAvailableEngine(f.Engine) && f.Normalization.IsValid() && f.Round.IsValid() && f.Expansion.IsValid() && f.Mask.IsValid()
+}
+
+// encryptBytes applies the FPE Feistel cipher on the passed bytes
+func (f FPECipher) encryptBytes(src string) (ciphered base256.Readable, err error) {
+	// Apply the FPE Feistel cipher
+	left, right, err := utils.Split(src)
 	if err != nil {
-		return "", err
+		return
 	}
-	hashed, err := hash.H([]byte(addition), f.Engine)
+	net := f.prepare()
+	defer net.destroy()
+	parts := []string{left, right}
+	for i := 0; i < f.Rounds; i++ {
+		left = right
+		if len(parts[1]) < len(parts[0]) {
+			neutral := xor.Neutral("0")
+			parts[1] += string(neutral)
+		}
+		rnd, e := net.round(parts[1], i)
+		if e != nil {
+			err = e
+			return
+		}
+		tmp := parts[0]
+		crop := false
+		if len(tmp)+1 == len(rnd) {
+			neutral := xor.Neutral(rnd[len(tmp):])
+			tmp += string(neutral)
+			crop = true
+		}
+		right, err = xor.String(tmp, rnd)
+		if err != nil {
+			return
+		}
+		if crop {
+			right = right[:len(right)-1]
+		}
+		parts = []string{left, right}
+	}
+	ciphered = base256.ToBase256Readable([]byte(parts[0] + parts[1]))
+	return
+}
+
+// encryptDigits applies the alternating Feistel cipher on the passed digits in the passed radix
+func (f FPECipher) encryptDigits(digits []int, radix int) ([]int, error) {
+	if f.SmallDomain && len(digits) <= SMALL_DOMAIN_MAX_LENGTH {
+		net := f.prepare()
+		defer net.destroy()
+		return f.permuteDigits(net, digits, radix, false)
+	}
+	if len(digits) == 1 {
+		return nil, exception.NewTooShortToEncryptError()
+	}
+	u := len(digits) / 2
+	left, right := digits[:u], digits[u:]
+	net := f.prepare()
+	defer net.destroy()
+	for i := 0; i < f.Rounds; i++ {
+		rnd, err := roundDigits(net, right, i, len(left), radix)
+		if err != nil {
+			return nil, err
+		}
+		tmp := make([]int, len(left))
+		for j := range left {
+			tmp[j] = (left[j] + rnd[j]) % radix
+		}
+		left = right
+		right = tmp
+	}
+	return append(left[:len(left):len(left)], right...), nil
+}
+
+// decryptDigits reverses the encryptDigits() method
+func (f FPECipher) decryptDigits(digits []int, radix int) ([]int, error) {
+	if f.SmallDomain && len(digits) <= SMALL_DOMAIN_MAX_LENGTH {
+		net := f.prepare()
+		defer net.destroy()
+		return f.permuteDigits(net, digits, radix, true)
+	}
+	if len(digits) == 1 {
+		return nil, exception.NewTooShortToEncryptError()
+	}
+	// Apply the alternating Feistel cipher in reverse order
+	u := len(digits) / 2
+	if f.Rounds%2 != 0 {
+		u = len(digits) - u
+	}
+	left, right := digits[:u], digits[u:]
+	net := f.prepare()
+	defer net.destroy()
+	for i := f.Rounds - 1; i >= 0; i-- {
+		rnd, err := roundDigits(net, left, i, len(right), radix)
+		if err != nil {
+			return nil, err
+		}
+		tmp := make([]int, len(right))
+		for j := range right {
+			tmp[j] = (right[j] - rnd[j] + radix) % radix
+		}
+		right = left
+		left = tmp
+	}
+	return append(left[:len(left):len(left)], right...), nil
+}
+
+// roundDigits derives from the passed half the `count` digits in the passed radix to add at the passed round index
+func roundDigits(net *network, half []int, index, count, radix int) ([]int, error) {
+	buf := make([]byte, 4*len(half))
+	for i, digit := range half {
+		binary.BigEndian.PutUint32(buf[4*i:], uint32(digit))
+	}
+	numbers, err := net.numbers(string(buf), index, count)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	digits := make([]int, count)
+	for i, n := range numbers {
+		digits[i] = int(n % uint32(radix))
+	}
+	return digits, nil
+}
+
+// Small-domain implementation
+
+// permute applies a balanced bit-level Feistel network to the passed value, walking the cycle until the result
+// falls back into [0, domain)
+func (f FPECipher) permute(net *network, value, domain uint64, reverse bool) (uint64, error) {
+	size := bits.Len64(domain - 1)
+	if size < 2 {
+		size = 2
+	}
+	size += size % 2
+	half := size / 2
+	mask := uint64(1)<<half - 1
+	for {
+		left, right := value>>half, value&mask
+		for i := 0; i < f.Rounds; i++ {
+			if reverse {
+				rnd, err := roundBits(net, left, domain, f.Rounds-i-1)
+				if err != nil {
+					return 0, err
+				}
+				left, right = right^(rnd&mask), left
+			} else {
+				rnd, err := roundBits(net, right, domain, i)
+				if err != nil {
+					return 0, err
+				}
+				left, right = right, left^(rnd&mask)
+			}
+		}
+		value = left<<half | right
+		if value < domain {
+			return value, nil
+		}
+	}
+}
+
+// permuteBytes ...
+func (f FPECipher) permuteBytes(net *network, data []byte, reverse bool) ([]byte, error) {
+	var value uint64
+	for _, b := range data {
+		value = value<<8 | uint64(b)
+	}
+	permuted, err := f.permute(net, value, uint64(1)<<(8*len(data)), reverse)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, len(data))
+	for i := len(buf) - 1; i >= 0; i-- {
+		buf[i] = byte(permuted)
+		permuted >>= 8
+	}
+	return buf, nil
+}
+
+// permuteDigits ...
+func (f FPECipher) permuteDigits(net *network, digits []int, radix int, reverse bool) ([]int, error) {
+	var value uint64
+	domain := uint64(1)
+	for _, digit := range digits {
+		value = value*uint64(radix) + uint64(digit)
+		domain *= uint64(radix)
 	}
-	hexHashed := utls.ToHex(hashed)
-	return utils.Extract(hexHashed, index, len(item)), nil
+	permuted, err := f.permute(net, value, domain, reverse)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]int, len(digits))
+	for i := len(buf) - 1; i >= 0; i-- {
+		buf[i] = int(permuted % uint64(radix))
+		permuted /= uint64(radix)
+	}
+	return buf, nil
+}
+
+// roundBits is the round function of the small-domain Feistel network, the domain being used as a tweak
+func roundBits(net *network, half, domain uint64, index int) (uint64, error) {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf, half)
+	binary.BigEndian.PutUint64(buf[8:], domain)
+	numbers, err := net.numbers(string(buf), index, 2)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(numbers[0])<<32 | uint64(numbers[1]), nil
 }
 
 //--- FUNCTIONS
@@ -189,8 +459,40 @@ func NewFPECipher(engine hash.Engine, key string, rounds int) *FPECipher {
 	}
 }
 
+// NewSecretFPECipher returns an FPECipher whose master key is the passed secret
+func NewSecretFPECipher(engine hash.Engine, secret *keys.Secret, rounds int) *FPECipher {
+	return &FPECipher{
+		Engine:   engine,
+		Rounds:   rounds,
+		Schedule: SecretKey{secret},
+	}
+}
+
 //--- utilities
 
+func toDigits(str string, within runes.Range) ([]int, error) {
+	if !utf8.ValidString(str) {
+		return nil, exception.NewOutOfRangeError()
+	}
+	var digits []int
+	for _, char := range str {
+		index := within.IndexOf(char)
+		if index == -1 {
+			return nil, exception.NewOutOfRangeError()
+		}
+		digits = append(digits, index)
+	}
+	return digits, nil
+}
+
+func fromDigits(digits []int, within runes.Range) string {
+	var str strings.Builder
+	for _, digit := range digits {
+		str.WriteRune(within.At(digit))
+	}
+	return str.String()
+}
+
 func uint64ToBytes(x uint64) []byte {
 	buf := make([]byte, 8)
 	binary.BigEndian.PutUint64(buf, x)
@@ -1,11 +1,15 @@
 package feistel_test
 
 import (
+	"fmt"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/cyrildever/feistel"
 	"github.com/cyrildever/feistel/common/utils/base256"
 	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/common/utils/runes"
 	"github.com/cyrildever/feistel/exception"
 	utls "github.com/cyrildever/go-utls/common/utils"
 	"gotest.tools/assert"
@@ -27,6 +31,27 @@ func TestFPEEncrypt(t *testing.T) {
 	assert.Assert(t, blake2.String() != expected)
 	expectedBlake2 := "¼u*$q0up¢"
 	assert.Equal(t, blake2.String(), expectedBlake2)
+
+	vectors := map[hash.Engine]string{
+		hash.BLAKE2b_512: "280050061d0f511d5e",
+		hash.BLAKE3:      "7f540b0b4056004d55",
+		hash.SHA_512:     "7d5c515e440d594353",
+		hash.SHAKE256:    "295805551058074103",
+	}
+	for engine, expectedHex := range vectors {
+		cipher := feistel.NewFPECipher(engine, "8ed9dcc1701c064f0fd7ae235f15143f989920e0ee9658bb7882c8d7d5f05692", 10)
+		found, err := cipher.Encrypt("Edgewhere")
+		assert.NilError(t, err)
+		assert.Equal(t, found.ToHex(), expectedHex)
+
+		// Long halves with extendable-output or wide engines
+		long := strings.Repeat("Edgewhere", 30)
+		found, err = cipher.Encrypt(long)
+		assert.NilError(t, err)
+		deciphered, err := cipher.Decrypt(found)
+		assert.NilError(t, err)
+		assert.Equal(t, deciphered, long)
+	}
 }
 
 // TestFPEDecrypt ...
@@ -157,16 +182,199 @@ func TestReadableNumber(t *testing.T) {
 }
 
 func BenchmarkEncrypt(b *testing.B) {
-	cipher := feistel.NewFPECipher(hash.SHA_256, "8ed9dcc1701c064f0fd7ae235f15143f989920e0ee9658bb7882c8d7d5f05692", 10)
+	short := "Edgewhere"
+	long := strings.Repeat("Edgewhere", 100)
+	for _, round := range []feistel.Round{feistel.LEGACY_ROUND, feistel.AES_ROUND} {
+		cipher := feistel.NewFPECipher(hash.SHA_256, "8ed9dcc1701c064f0fd7ae235f15143f989920e0ee9658bb7882c8d7d5f05692", 10)
+		cipher.Round = round
+		name := string(hash.SHA_256)
+		if round == feistel.AES_ROUND {
+			name = string(round)
+		}
+		for _, src := range []string{short, long} {
+			b.Run(fmt.Sprintf("%s/%d", name, len(src)), func(b *testing.B) {
+				b.ReportAllocs()
+				b.ResetTimer()
+				b.StartTimer()
+
+				for i := 0; i < b.N; i++ {
+					_, err := cipher.Encrypt(src)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestFPERunes ...
+func TestFPERunes(t *testing.T) {
+	cipher := feistel.NewFPECipher(hash.SHA_256, "some-32-byte-long-key-to-be-safe", 10)
+
+	source := "Aïda Çelik ☕ 日本"
+	obfuscated, err := cipher.EncryptRunes(source, runes.BMP)
+	assert.NilError(t, err)
+	assert.Assert(t, utf8.ValidString(obfuscated))
+	assert.Equal(t, utf8.RuneCountInString(obfuscated), utf8.RuneCountInString(source))
+	assert.Assert(t, obfuscated != source)
+
+	deciphered, err := cipher.DecryptRunes(obfuscated, runes.BMP)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, source)
+
+	lowercase := runes.Range{Min: 'a', Max: 'z'}
+	obfuscated, err = cipher.EncryptRunes("edgewhere", lowercase)
+	assert.NilError(t, err)
+	assert.Equal(t, obfuscated, "frnkdzkvu")
+
+	// Long constant inputs leave no pattern, whatever the range
+	for _, within := range []runes.Range{lowercase, runes.BMP} {
+		constant := strings.Repeat("a", 40)
+		obfuscated, err = cipher.EncryptRunes(constant, within)
+		assert.NilError(t, err)
+		assert.Assert(t, !hasPeriod(obfuscated), obfuscated)
+		deciphered, err = cipher.DecryptRunes(obfuscated, within)
+		assert.NilError(t, err)
+		assert.Equal(t, deciphered, constant)
+	}
 
-	b.ReportAllocs()
-	b.ResetTimer()
-	b.StartTimer()
+	// Odd number of rounds
+	cipher = feistel.NewFPECipher(hash.BLAKE2b, "some-32-byte-long-key-to-be-safe", 11)
+	obfuscated, err = cipher.EncryptRunes("edgewhere", lowercase)
+	assert.NilError(t, err)
+	assert.Equal(t, len(obfuscated), len("edgewhere"))
+	for _, char := range obfuscated {
+		assert.Assert(t, lowercase.Contains(char))
+	}
+	deciphered, err = cipher.DecryptRunes(obfuscated, lowercase)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, "edgewhere")
 
-	for i := 0; i < b.N; i++ {
-		_, err := cipher.Encrypt("Edgewhere")
-		if err != nil {
-			b.Fatal(err)
+	// Out of range
+	_, err = cipher.EncryptRunes("Edgewhere", lowercase)
+	_, ok := err.(*exception.OutOfRangeError)
+	assert.Assert(t, ok)
+	_, err = cipher.EncryptRunes(string([]byte{0xff, 0xfe}), runes.UNICODE)
+	_, ok = err.(*exception.OutOfRangeError)
+	assert.Assert(t, ok)
+}
+
+// TestFPESmallDomain ...
+func TestFPESmallDomain(t *testing.T) {
+	cipher := feistel.NewFPECipher(hash.SHA_256, "some-32-byte-long-key-to-be-safe", 10)
+
+	// Legacy mode refuses to echo single characters
+	_, err := cipher.EncryptString("a")
+	assert.Error(t, err, "too short to be encrypted: use the small-domain mode")
+	_, ok := err.(*exception.TooShortToEncryptError)
+	assert.Assert(t, ok)
+	_, err = cipher.Decrypt(base256.ToBase256Readable([]byte("a"))) // But still deciphers what it used to produce
+	assert.NilError(t, err)
+	_, err = cipher.EncryptRunes("é", runes.LATIN_1)
+	_, ok = err.(*exception.TooShortToEncryptError)
+	assert.Assert(t, ok)
+
+	cipher.SmallDomain = true
+	obfuscated, err := cipher.EncryptString("a")
+	assert.NilError(t, err)
+	assert.Equal(t, obfuscated.Len(), 1)
+	assert.Assert(t, obfuscated.String(true) != "a")
+	deciphered, err := cipher.DecryptString(obfuscated)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, "a")
+
+	// Every single byte is mapped to a distinct single byte
+	seen := make(map[string]bool)
+	for b := 0; b < 256; b++ {
+		src := string([]byte{byte(b)})
+		obfuscated, err := cipher.Encrypt(src)
+		assert.NilError(t, err)
+		assert.Equal(t, len(obfuscated.Bytes()), 1)
+		seen[obfuscated.String()] = true
+		deciphered, err := cipher.Decrypt(obfuscated)
+		assert.NilError(t, err)
+		assert.Equal(t, deciphered, src)
+	}
+	assert.Equal(t, len(seen), 256)
+
+	// Cycle walking keeps very short rune inputs within a non-power-of-two range
+	digits := runes.Range{Min: '0', Max: '9'}
+	seen = make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		src := fmt.Sprintf("%02d", i)
+		obfuscated, err := cipher.EncryptRunes(src, digits)
+		assert.NilError(t, err)
+		assert.Equal(t, len(obfuscated), 2)
+		seen[obfuscated] = true
+		deciphered, err := cipher.DecryptRunes(obfuscated, digits)
+		assert.NilError(t, err)
+		assert.Equal(t, deciphered, src)
+	}
+	assert.Equal(t, len(seen), 100)
+
+	// Longer inputs are left to the usual Feistel cipher
+	obfuscated, err = cipher.Encrypt("Edgewhere")
+	assert.NilError(t, err)
+	legacy, _ := feistel.NewFPECipher(hash.SHA_256, "some-32-byte-long-key-to-be-safe", 10).Encrypt("Edgewhere")
+	assert.Equal(t, obfuscated, legacy)
+}
+
+// TestFPENormalization ...
+func TestFPENormalization(t *testing.T) {
+	composed := "Am\u00e9lie"
+	decomposed := "Ame\u0301lie"
+	assert.Assert(t, composed != decomposed)
+
+	cipher := feistel.NewFPECipher(hash.SHA_256, "some-32-byte-long-key-to-be-safe", 10)
+	fromComposed, _ := cipher.EncryptString(composed)
+	fromDecomposed, _ := cipher.EncryptString(decomposed)
+	assert.Assert(t, fromComposed != fromDecomposed)
+
+	cipher.Normalization = runes.NFC
+	fromComposed, err := cipher.EncryptString(composed)
+	assert.NilError(t, err)
+	fromDecomposed, err = cipher.EncryptString(decomposed)
+	assert.NilError(t, err)
+	assert.Equal(t, fromComposed, fromDecomposed)
+	deciphered, err := cipher.DecryptString(fromDecomposed)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, composed)
+
+	cipher.Normalization = runes.NFD
+	obfuscated, err := cipher.EncryptRunes(composed, runes.BMP)
+	assert.NilError(t, err)
+	assert.Equal(t, utf8.RuneCountInString(obfuscated), utf8.RuneCountInString(decomposed))
+	deciphered, err = cipher.DecryptRunes(obfuscated, runes.BMP)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, decomposed)
+
+	// Numbers are never normalized
+	number, err := cipher.EncryptNumber(123456789)
+	assert.NilError(t, err)
+	deobfuscated, err := cipher.DecryptNumber(number)
+	assert.NilError(t, err)
+	assert.Equal(t, deobfuscated, uint64(123456789))
+
+	cipher.Normalization = "unknown"
+	_, err = cipher.EncryptString(composed)
+	_, ok := err.(*exception.WrongCipherParametersError)
+	assert.Assert(t, ok)
+}
+
+// hasPeriod tells whether most characters of the passed string are repeated at some short, fixed distance
+func hasPeriod(str string) bool {
+	chars := []rune(str)
+	for distance := 1; distance <= len(chars)/4; distance++ {
+		repeated := 0
+		for i := distance; i < len(chars); i++ {
+			if chars[i] == chars[i-distance] {
+				repeated++
+			}
+		}
+		if 2*repeated > len(chars)-distance {
+			return true
 		}
 	}
+	return false
 }
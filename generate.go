@@ -0,0 +1,191 @@
+package feistel
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/exception"
+	"github.com/cyrildever/feistel/keys"
+)
+
+const (
+	KEY_SET_FORMAT  = "feistel/custom-keys"
+	KEY_SET_VERSION = 1
+
+	MIN_GENERATED_KEY_BYTES = 16
+)
+
+//--- TYPES
+
+// KeySet is the JSON export format of the round keys of a CustomCipher, eg.
+//
+//	{"format":"feistel/custom-keys","version":1,"engine":"sha-256","keys":["<hex>","<hex>",...]}
+//
+// the `round`, `expansion` and `mask` options being only present when they're not the legacy ones
+type KeySet struct {
+	Format    string      `json:"format"`
+	Version   int         `json:"version"`
+	Engine    hash.Engine `json:"engine"`
+	Round     Round       `json:"round,omitempty"`
+	Expansion Expansion   `json:"expansion,omitempty"`
+	Mask      Mask        `json:"mask,omitempty"`
+	Keys      []string    `json:"keys"`
+}
+
+//--- METHODS
+
+// ExportKeys returns the KeySet of the cipher as JSON.
+// NB: The output holds the keys in clear, so handle it as a secret.
+func (cc CustomCipher) ExportKeys() ([]byte, error) {
+	if !cc.isValid() {
+		return nil, exception.NewWrongCipherParametersError()
+	}
+	set := KeySet{
+		Format:    KEY_SET_FORMAT,
+		Version:   KEY_SET_VERSION,
+		Engine:    orDefault(cc.Engine),
+		Round:     cc.Round,
+		Expansion: cc.Expansion,
+		Mask:      cc.Mask,
+		Keys:      cc.roundKeys(),
+	}
+	return json.Marshal(set)
+}
+
+// roundKeys returns the keys of the cipher, be they secrets or not
+func (cc CustomCipher) roundKeys() []string {
+	if len(cc.Secrets) == 0 {
+		return cc.Keys
+	}
+	keys := make([]string, len(cc.Secrets))
+	for i, secret := range cc.Secrets {
+		keys[i] = string(secret.Bytes())
+	}
+	return keys
+}
+
+//--- FUNCTIONS
+
+// GenerateCustomCipher returns a CustomCipher using the SHA-256 engine with `rounds` round keys, each being the
+// hexadecimal string of `keyBytes` random bytes drawn from crypto/rand
+func GenerateCustomCipher(rounds, keyBytes int) (cipher *CustomCipher, err error) {
+	if rounds < 2 {
+		return nil, exception.NewTooFewRoundsError()
+	}
+	if keyBytes < MIN_GENERATED_KEY_BYTES {
+		return nil, exception.NewWeakKeyError()
+	}
+	secrets := make([]*keys.Secret, rounds)
+	defer func() {
+		if err != nil {
+			for _, secret := range secrets {
+				secret.Destroy()
+			}
+		}
+	}()
+	roundKeys := make([][]byte, rounds)
+	random := make([]byte, keyBytes)
+	defer keys.Zeroize(random)
+	encoded := make([]byte, hex.EncodedLen(keyBytes))
+	defer keys.Zeroize(encoded)
+	for i := range secrets {
+		if _, err := rand.Read(random); err != nil {
+			return nil, err
+		}
+		hex.Encode(encoded, random)
+		secrets[i] = keys.NewSecret(encoded)
+		roundKeys[i] = secrets[i].Bytes()
+	}
+	if err := validateRoundKeys(roundKeys); err != nil {
+		return nil, err
+	}
+	return NewSecretCustomCipher(hash.SHA_256, secrets), nil
+}
+
+// ImportCustomCipher returns the CustomCipher of the passed JSON KeySet after validating its round keys
+func ImportCustomCipher(data []byte) (*CustomCipher, error) {
+	var set KeySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	if set.Format != KEY_SET_FORMAT || set.Version != KEY_SET_VERSION {
+		return nil, exception.NewUnknownVersionError()
+	}
+	if err := ValidateRoundKeys(set.Keys); err != nil {
+		return nil, err
+	}
+	cipher := NewCustomCipherWithEngine(set.Engine, set.Keys)
+	cipher.Round, cipher.Expansion, cipher.Mask = set.Round, set.Expansion, set.Mask
+	if !cipher.isValid() {
+		return nil, exception.NewWrongCipherParametersError()
+	}
+	return cipher, nil
+}
+
+// ValidateRoundKeys returns a RelatedKeysError if two of the passed keys are duplicates or trivially related,
+// ie. if one is (case-insensitively) a rotation, a substring or the reverse of the other,
+// or if they differ in less than a quarter of their characters
+func ValidateRoundKeys(keys []string) error {
+	roundKeys := make([][]byte, len(keys))
+	for i, key := range keys {
+		roundKeys[i] = []byte(key)
+	}
+	return validateRoundKeys(roundKeys)
+}
+
+//--- utilities
+
+// validateRoundKeys is the ValidateRoundKeys() function working on bytes, so that no copy of a secret key is left in a string
+func validateRoundKeys(keys [][]byte) error {
+	if len(keys) < 2 {
+		return exception.NewTooFewRoundsError()
+	}
+	for i, key := range keys {
+		if len(key) == 0 {
+			return exception.NewWeakKeyError()
+		}
+		for _, other := range keys[:i] {
+			if related(key, other) {
+				return exception.NewRelatedKeysError()
+			}
+		}
+	}
+	return nil
+}
+
+// related tells whether the passed keys are equal, rotated, reversed or included in one another, or hardly differ,
+// case-insensitively
+func related(a, b []byte) bool {
+	a, b = bytes.ToLower(a), bytes.ToLower(b)
+	defer keys.Zeroize(a)
+	defer keys.Zeroize(b)
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	doubled := append(bytes.Clone(a), a...)
+	defer keys.Zeroize(doubled)
+	if bytes.Contains(doubled, b) {
+		return true
+	}
+	reversed := bytes.Clone(b)
+	defer keys.Zeroize(reversed)
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+	if bytes.Contains(a, reversed) {
+		return true
+	}
+	if len(a) == len(b) {
+		differences := 0
+		for i := range a {
+			if a[i] != b[i] {
+				differences++
+			}
+		}
+		return 4*differences < len(a)
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package feistel_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cyrildever/feistel"
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/exception"
+	"gotest.tools/assert"
+)
+
+// TestGenerateCustomCipher ...
+func TestGenerateCustomCipher(t *testing.T) {
+	cipher, err := feistel.GenerateCustomCipher(10, 32)
+	assert.NilError(t, err)
+	assert.Equal(t, len(cipher.Secrets), 10)
+	for _, secret := range cipher.Secrets {
+		assert.Equal(t, secret.Len(), 64)
+	}
+	obfuscated, err := cipher.Encrypt(katSource)
+	assert.NilError(t, err)
+	deciphered, err := cipher.Decrypt(obfuscated)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, katSource)
+
+	other, _ := feistel.GenerateCustomCipher(10, 32)
+	assert.Assert(t, string(other.Secrets[0].Bytes()) != string(cipher.Secrets[0].Bytes()))
+
+	// Export and import
+	exported, err := cipher.ExportKeys()
+	assert.NilError(t, err)
+	assert.Assert(t, strings.HasPrefix(string(exported), `{"format":"feistel/custom-keys","version":1,"engine":"sha-256","keys":["`))
+	imported, err := feistel.ImportCustomCipher(exported)
+	assert.NilError(t, err)
+	found, err := imported.Encrypt(katSource)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, found, obfuscated)
+
+	v2, _ := feistel.NewVersionedCustomCipher(feistel.V2, hash.BLAKE2b, []string{katKeys[0], katKey})
+	exported, err = v2.ExportKeys()
+	assert.NilError(t, err)
+	assert.Equal(t, string(exported), `{"format":"feistel/custom-keys","version":1,"engine":"blake-2b-256","round":"hmac","expansion":"counter-v1","mask":"raw-v1","keys":["`+katKeys[0]+`","`+katKey+`"]}`)
+	imported, err = feistel.ImportCustomCipher(exported)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *imported, *v2)
+
+	_, err = feistel.GenerateCustomCipher(1, 32)
+	assert.Error(t, err, exception.NewTooFewRoundsError().Error())
+	_, err = feistel.GenerateCustomCipher(10, 8)
+	assert.Error(t, err, exception.NewWeakKeyError().Error())
+	exported, _ = feistel.NewCustomCipher(katKeys).ExportKeys()
+	_, err = feistel.ImportCustomCipher(exported)
+	assert.Error(t, err, exception.NewRelatedKeysError().Error())
+	_, err = feistel.ImportCustomCipher([]byte(`{"format":"feistel/custom-keys","version":2,"engine":"sha-256","keys":["a","b"]}`))
+	assert.Error(t, err, exception.NewUnknownVersionError().Error())
+	_, err = feistel.ImportCustomCipher([]byte(`{"format":"feistel/custom-keys","version":1,"engine":"md5","keys":["` + katKey + `","` + katKeys[0] + `"]}`))
+	assert.Error(t, err, exception.NewWrongCipherParametersError().Error())
+}
+
+// TestValidateRoundKeys ...
+func TestValidateRoundKeys(t *testing.T) {
+	assert.NilError(t, feistel.ValidateRoundKeys([]string{katKeys[0], katKeys[1], katKey}))
+	err := feistel.ValidateRoundKeys(katKeys) // The third key is the reverse of the second one
+	assert.Error(t, err, exception.NewRelatedKeysError().Error())
+
+	rotated := katKey[10:] + katKey[:10]
+	reversed := []byte(katKey)
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+	oneByteOff := "9" + katKey[1:]
+	for _, related := range []string{katKey, strings.ToUpper(katKey), rotated, katKey[:32], string(reversed), oneByteOff} {
+		err := feistel.ValidateRoundKeys([]string{katKeys[0], katKey, related})
+		assert.Error(t, err, exception.NewRelatedKeysError().Error(), related)
+	}
+
+	err = feistel.ValidateRoundKeys([]string{katKey})
+	assert.Error(t, err, exception.NewTooFewRoundsError().Error())
+	err = feistel.ValidateRoundKeys([]string{katKey, ""})
+	assert.Error(t, err, exception.NewWeakKeyError().Error())
+}
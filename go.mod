@@ -8,7 +8,10 @@ require (
 	github.com/cyrildever/go-utls v1.10.6
 	github.com/ethereum/go-ethereum v1.15.8
 	golang.org/x/crypto v0.37.0
+	golang.org/x/text v0.24.0
+	gopkg.in/yaml.v3 v3.0.1
 	gotest.tools v2.2.0+incompatible
+	lukechampine.com/blake3 v1.4.1
 )
 
 require (
@@ -18,6 +21,7 @@ require (
 	github.com/gofrs/uuid v4.4.0+incompatible // indirect
 	github.com/google/go-cmp v0.7.0 // indirect
 	github.com/holiman/uint256 v1.3.2 // indirect
+	github.com/klauspost/cpuid/v2 v2.0.9 // indirect
 	github.com/mattn/go-colorable v0.1.14 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
@@ -26,5 +30,4 @@ require (
 	go.mongodb.org/mongo-driver v1.17.3 // indirect
 	golang.org/x/net v0.39.0 // indirect
 	golang.org/x/sys v0.32.0 // indirect
-	golang.org/x/text v0.24.0 // indirect
 )
@@ -0,0 +1,55 @@
+package feistel
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"strings"
+
+	"github.com/cyrildever/feistel/exception"
+	utls "github.com/cyrildever/go-utls/common/utils"
+)
+
+const (
+	KCV_INPUT  = "feistel/kcv" // The constant encrypted to compute a key check value
+	KCV_LENGTH = 4             // Length in bytes of a key check value
+)
+
+//--- METHODS
+
+// VerifyKCV checks the passed key check value against the key of the passed ID
+func (k *Keyring) VerifyKCV(keyID, kcv string) error {
+	cipher, err := k.Cipher(keyID)
+	if err != nil {
+		return err
+	}
+	return VerifyKCV(cipher, kcv)
+}
+
+//--- FUNCTIONS
+
+// KCV returns the key check value of the passed cipher, ie. a short fingerprint of its keys and all its parameters
+// (engine, rounds, round function, etc.) that may be stored next to the obfuscated data:
+//
+//	KCV = hex(SHA-256(Encrypt(KCV_INPUT)))[:2*KCV_LENGTH]
+//
+// where Encrypt() returns the raw bytes of the obfuscated constant (ie. its Bytes() for the FPECipher).
+func KCV(cipher Obfuscator) (string, error) {
+	ciphered, err := cipher.Obfuscate(KCV_INPUT)
+	if err != nil {
+		return "", err
+	}
+	hashed := sha256.Sum256(ciphered)
+	return utls.ToHex(hashed[:KCV_LENGTH]), nil
+}
+
+// VerifyKCV returns a WrongKeyError if the passed key check value doesn't match the cipher's
+func VerifyKCV(cipher Obfuscator, kcv string) error {
+	expected, err := KCV(cipher)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(kcv))) != 1 {
+		return exception.NewWrongKeyError()
+	}
+	return nil
+}
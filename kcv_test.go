@@ -0,0 +1,46 @@
+package feistel_test
+
+import (
+	"testing"
+
+	"github.com/cyrildever/feistel"
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/exception"
+	"gotest.tools/assert"
+)
+
+// TestKCV ...
+func TestKCV(t *testing.T) {
+	vectors := []struct {
+		cipher   feistel.Obfuscator
+		expected string
+	}{
+		{feistel.NewCipher(katKey, 10), "eaebcf70"},
+		{feistel.NewCipher(katKey, 12), "ece165a6"},
+		{feistel.NewCustomCipher(katKeys), "327db1cc"},
+		{feistel.NewFPECipher(hash.SHA_256, katKey, 10), "e39f7d9b"},
+		{feistel.NewFPECipher(hash.BLAKE2b, katKey, 10), "9c55f6a0"},
+	}
+	for _, vector := range vectors {
+		kcv, err := feistel.KCV(vector.cipher)
+		assert.NilError(t, err)
+		assert.Equal(t, kcv, vector.expected)
+		assert.NilError(t, feistel.VerifyKCV(vector.cipher, vector.expected))
+	}
+	assert.NilError(t, feistel.VerifyKCV(feistel.NewCipher(katKey, 10), "EAEBCF70"))
+
+	err := feistel.VerifyKCV(feistel.NewCipher(katShortKey, 10), "eaebcf70")
+	assert.Error(t, err, exception.NewWrongKeyError().Error())
+	v2, _ := feistel.NewVersionedCipher(feistel.V2, hash.SHA_256, katKey, 10)
+	err = feistel.VerifyKCV(v2, "eaebcf70")
+	assert.Error(t, err, exception.NewWrongKeyError().Error())
+	err = feistel.VerifyKCV(feistel.NewCipher("", 10), "eaebcf70")
+	assert.Error(t, err, exception.NewWrongCipherParametersError().Error())
+
+	keyring, _ := feistel.NewKeyring("2025", feistel.NewCipher(katKey, 10))
+	assert.NilError(t, keyring.VerifyKCV("2025", "eaebcf70"))
+	err = keyring.VerifyKCV("2025", "327db1cc")
+	assert.Error(t, err, exception.NewWrongKeyError().Error())
+	err = keyring.VerifyKCV("2024", "eaebcf70")
+	assert.Error(t, err, exception.NewUnknownKeyIDError().Error())
+}
@@ -0,0 +1,208 @@
+package feistel
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/cyrildever/feistel/common/utils/base256"
+	"github.com/cyrildever/feistel/exception"
+)
+
+//--- TYPES
+
+// Obfuscator is the common interface of the three ciphers working on raw bytes,
+// ie. the bytes of the base-256 readable result for the FPECipher
+type Obfuscator interface {
+	Obfuscate(src string) ([]byte, error)
+	Deobfuscate(ciphered []byte) (string, error)
+}
+
+// Keyring holds ciphers under key IDs, the active one being used for encryption and the retired ones only for decryption.
+// It's safe for concurrent use. Its zero value is an empty keyring without any active key until Rotate() is called.
+type Keyring struct {
+	mu      sync.RWMutex
+	active  string
+	ciphers map[string]Obfuscator
+}
+
+//--- METHODS
+
+// Obfuscate ...
+func (c Cipher) Obfuscate(src string) ([]byte, error) {
+	return c.Encrypt(src)
+}
+
+// Deobfuscate ...
+func (c Cipher) Deobfuscate(ciphered []byte) (string, error) {
+	return c.Decrypt(ciphered)
+}
+
+// Obfuscate ...
+func (cc CustomCipher) Obfuscate(src string) ([]byte, error) {
+	return cc.Encrypt(src)
+}
+
+// Deobfuscate ...
+func (cc CustomCipher) Deobfuscate(ciphered []byte) (string, error) {
+	return cc.Decrypt(ciphered)
+}
+
+// Obfuscate ...
+func (f FPECipher) Obfuscate(src string) ([]byte, error) {
+	ciphered, err := f.Encrypt(src)
+	if err != nil {
+		return nil, err
+	}
+	return ciphered.Bytes(), nil
+}
+
+// Deobfuscate ...
+func (f FPECipher) Deobfuscate(ciphered []byte) (string, error) {
+	return f.Decrypt(base256.ToBase256Readable(ciphered))
+}
+
+// ActiveID returns the ID of the key currently used for encryption
+func (k *Keyring) ActiveID() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.active
+}
+
+// Add registers a retired key, ie. only usable for decryption
+func (k *Keyring) Add(keyID string, cipher Obfuscator) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.add(keyID, cipher)
+}
+
+// Cipher returns the cipher of the passed key ID
+func (k *Keyring) Cipher(keyID string) (Obfuscator, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	cipher, ok := k.ciphers[keyID]
+	if !ok {
+		return nil, exception.NewUnknownKeyIDError()
+	}
+	return cipher, nil
+}
+
+// Decrypt uses the key of the passed ID, be it active or retired
+func (k *Keyring) Decrypt(keyID string, ciphered []byte) (string, error) {
+	cipher, err := k.Cipher(keyID)
+	if err != nil {
+		return "", err
+	}
+	return cipher.Deobfuscate(ciphered)
+}
+
+// Encrypt uses the active key and returns its ID along with the obfuscated data
+func (k *Keyring) Encrypt(src string) (keyID string, ciphered []byte, err error) {
+	keyID, cipher, err := k.activeCipher()
+	if err != nil {
+		return
+	}
+	ciphered, err = cipher.Obfuscate(src)
+	return
+}
+
+// IDs returns the sorted IDs of all the keys, active and retired
+func (k *Keyring) IDs() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	ids := make([]string, 0, len(k.ciphers))
+	for id := range k.ciphers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// IsRetired ...
+func (k *Keyring) IsRetired(keyID string) bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	_, ok := k.ciphers[keyID]
+	return ok && keyID != k.active
+}
+
+// Reencrypt deciphers the passed data with the `fromKeyID` key and encrypts the result with the `toKeyID` key,
+// which must be the active one
+func (k *Keyring) Reencrypt(ciphered []byte, fromKeyID, toKeyID string) ([]byte, error) {
+	k.mu.RLock()
+	from, fromOK := k.ciphers[fromKeyID]
+	to, toOK := k.ciphers[toKeyID]
+	active := k.active
+	k.mu.RUnlock()
+	if !fromOK || !toOK {
+		return nil, exception.NewUnknownKeyIDError()
+	}
+	if toKeyID != active {
+		return nil, exception.NewRetiredKeyError()
+	}
+	deciphered, err := from.Deobfuscate(ciphered)
+	if err != nil {
+		return nil, err
+	}
+	return to.Obfuscate(deciphered)
+}
+
+// Remove deletes a retired key from the keyring
+func (k *Keyring) Remove(keyID string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.ciphers[keyID]; !ok {
+		return exception.NewUnknownKeyIDError()
+	}
+	if keyID == k.active {
+		return exception.NewActiveKeyRemovalError()
+	}
+	delete(k.ciphers, keyID)
+	return nil
+}
+
+// Rotate registers a new key and makes it the active one, the previous active key being retired
+func (k *Keyring) Rotate(keyID string, cipher Obfuscator) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := k.add(keyID, cipher); err != nil {
+		return err
+	}
+	k.active = keyID
+	return nil
+}
+
+// activeCipher returns the active key ID along with its cipher
+func (k *Keyring) activeCipher() (string, Obfuscator, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	cipher, ok := k.ciphers[k.active]
+	if !ok {
+		return "", nil, exception.NewNoActiveKeyError()
+	}
+	return k.active, cipher, nil
+}
+
+func (k *Keyring) add(keyID string, cipher Obfuscator) error {
+	if keyID == "" || cipher == nil {
+		return exception.NewWrongCipherParametersError()
+	}
+	if _, exists := k.ciphers[keyID]; exists {
+		return exception.NewDuplicateKeyIDError()
+	}
+	if k.ciphers == nil {
+		k.ciphers = make(map[string]Obfuscator)
+	}
+	k.ciphers[keyID] = cipher
+	return nil
+}
+
+//--- FUNCTIONS
+
+// NewKeyring returns a keyring whose active key is the passed one
+func NewKeyring(keyID string, cipher Obfuscator) (*Keyring, error) {
+	keyring := &Keyring{}
+	if err := keyring.Rotate(keyID, cipher); err != nil {
+		return nil, err
+	}
+	return keyring, nil
+}
@@ -0,0 +1,91 @@
+package feistel_test
+
+import (
+	"testing"
+
+	"github.com/cyrildever/feistel"
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/exception"
+	"gotest.tools/assert"
+)
+
+// TestKeyring ...
+func TestKeyring(t *testing.T) {
+	source := "my-source-data"
+
+	keyring, err := feistel.NewKeyring("2024", feistel.NewCipher(katKey, 10))
+	assert.NilError(t, err)
+	assert.Equal(t, keyring.ActiveID(), "2024")
+
+	keyID, obfuscated, err := keyring.Encrypt(source)
+	assert.NilError(t, err)
+	assert.Equal(t, keyID, "2024")
+	expected, _ := feistel.NewCipher(katKey, 10).Encrypt(source)
+	assert.DeepEqual(t, obfuscated, expected)
+
+	err = keyring.Rotate("2025", feistel.NewCustomCipher(katKeys))
+	assert.NilError(t, err)
+	fpe, _ := feistel.NewVersionedFPECipher(feistel.V2, hash.SHA_256, katShortKey, 10)
+	err = keyring.Rotate("2026", fpe)
+	assert.NilError(t, err)
+	assert.Equal(t, keyring.ActiveID(), "2026")
+	assert.DeepEqual(t, keyring.IDs(), []string{"2024", "2025", "2026"})
+	assert.Assert(t, keyring.IsRetired("2024"))
+	assert.Assert(t, !keyring.IsRetired("2026"))
+	assert.Assert(t, !keyring.IsRetired("unknown"))
+
+	// Retired keys still decrypt
+	deciphered, err := keyring.Decrypt("2024", obfuscated)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, source)
+
+	// Re-encrypt from a Cipher to an FPECipher
+	reencrypted, err := keyring.Reencrypt(obfuscated, "2024", "2026")
+	assert.NilError(t, err)
+	readable, _ := fpe.EncryptString(source)
+	assert.DeepEqual(t, reencrypted, readable.Bytes())
+	deciphered, err = keyring.Decrypt("2026", reencrypted)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, source)
+
+	_, err = keyring.Reencrypt(obfuscated, "2024", "2025")
+	assert.Error(t, err, exception.NewRetiredKeyError().Error())
+	_, err = keyring.Reencrypt(obfuscated, "2023", "2026")
+	assert.Error(t, err, exception.NewUnknownKeyIDError().Error())
+	_, err = keyring.Decrypt("2023", obfuscated)
+	assert.Error(t, err, exception.NewUnknownKeyIDError().Error())
+
+	err = keyring.Add("2025", feistel.NewCipher(katShortKey, 10))
+	assert.Error(t, err, exception.NewDuplicateKeyIDError().Error())
+	err = keyring.Add("2023", feistel.NewCipher(katShortKey, 10))
+	assert.NilError(t, err)
+	assert.Assert(t, keyring.IsRetired("2023"))
+
+	err = keyring.Remove("2026")
+	assert.Error(t, err, exception.NewActiveKeyRemovalError().Error())
+	err = keyring.Remove("2024")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, keyring.IDs(), []string{"2023", "2025", "2026"})
+
+	_, err = feistel.NewKeyring("", feistel.NewCipher(katKey, 10))
+	assert.Error(t, err, exception.NewWrongCipherParametersError().Error())
+
+	// The zero value is usable
+	var empty feistel.Keyring
+	_, _, err = empty.Encrypt(katSource)
+	assert.Error(t, err, exception.NewNoActiveKeyError().Error())
+	_, err = empty.Seal(katSource)
+	assert.Error(t, err, exception.NewNoActiveKeyError().Error())
+	err = empty.Add("2024", feistel.NewCipher(katKey, 10))
+	assert.NilError(t, err)
+	_, _, err = empty.Encrypt(katSource)
+	assert.Error(t, err, exception.NewNoActiveKeyError().Error())
+	err = empty.Rotate("2025", feistel.NewCipher(katShortKey, 10))
+	assert.NilError(t, err)
+	keyID, obfuscated, err = empty.Encrypt(katSource)
+	assert.NilError(t, err)
+	assert.Equal(t, keyID, "2025")
+	deciphered, err = empty.Decrypt(keyID, obfuscated)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, katSource)
+}
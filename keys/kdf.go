@@ -0,0 +1,230 @@
+package keys
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	stdhash "hash"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/exception"
+	utls "github.com/cyrildever/go-utls/common/utils"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	DEFAULT_KEY_LENGTH  = 32 // 256-bit keys, as recommended for the ciphers
+	DEFAULT_SALT_LENGTH = 16
+)
+
+// Upper bounds of the cost parameters, so that tampered parameters can't exhaust the memory or the CPU
+const (
+	MAX_KEY_LENGTH        = 1024     // In bytes
+	MAX_MEMORY            = 1 << 20  // In KiB, ie. 1 GiB for Argon2id and scrypt (128 * N * r bytes)
+	MAX_TIME              = 100      // Argon2id passes
+	MAX_SCRYPT_P          = 64       // scrypt parallelization
+	MAX_PBKDF2_ITERATIONS = 10000000 // PBKDF2 iterations
+)
+
+//--- TYPES
+
+// Algorithm defines the key derivation function to use on passphrases
+type Algorithm string
+
+const (
+	ARGON2ID Algorithm = "argon2id"
+	PBKDF2   Algorithm = "pbkdf2"
+	SCRYPT   Algorithm = "scrypt"
+)
+
+// Parameters holds everything needed to re-derive a key from a passphrase, except the passphrase itself.
+// They're meant to be stored next to the obfuscated data, either as JSON or through their String() representation.
+type Parameters struct {
+	Algorithm Algorithm `json:"algorithm"`
+	Salt      []byte    `json:"salt"`
+	KeyLength int       `json:"keyLength"`
+
+	// Argon2id
+	Time    uint32 `json:"time,omitempty"`
+	Memory  uint32 `json:"memory,omitempty"` // In KiB
+	Threads uint8  `json:"threads,omitempty"`
+
+	// scrypt
+	N int `json:"n,omitempty"`
+	R int `json:"r,omitempty"`
+	P int `json:"p,omitempty"`
+
+	// PBKDF2
+	Iterations int         `json:"iterations,omitempty"`
+	Engine     hash.Engine `json:"engine,omitempty"`
+}
+
+//--- METHODS
+
+// Derive returns the raw key derived from the passed passphrase
+func (p Parameters) Derive(passphrase string) ([]byte, error) {
+	if len(passphrase) == 0 || !p.IsValid() {
+		return nil, exception.NewWrongKDFParametersError()
+	}
+	switch p.Algorithm {
+	case ARGON2ID:
+		return argon2.IDKey([]byte(passphrase), p.Salt, p.Time, p.Memory, p.Threads, uint32(p.KeyLength)), nil
+	case SCRYPT:
+		return scrypt.Key([]byte(passphrase), p.Salt, p.N, p.R, p.P, p.KeyLength)
+	default:
+		return pbkdf2.Key([]byte(passphrase), p.Salt, p.Iterations, p.KeyLength, func() stdhash.Hash {
+			hasher, _ := hash.New(p.Engine) // Already checked
+			return hasher
+		}), nil
+	}
+}
+
+// DeriveKey returns the hexadecimal string of the key derived from the passed passphrase, ready to be used by the ciphers
+func (p Parameters) DeriveKey(passphrase string) (string, error) {
+	key, err := p.Derive(passphrase)
+	if err != nil {
+		return "", err
+	}
+	return utls.ToHex(key), nil
+}
+
+// IsValid ...
+func (p Parameters) IsValid() bool {
+	if len(p.Salt) == 0 || p.KeyLength <= 0 || p.KeyLength > MAX_KEY_LENGTH {
+		return false
+	}
+	switch p.Algorithm {
+	case ARGON2ID:
+		return p.Time > 0 && p.Time <= MAX_TIME && p.Memory >= 8*uint32(p.Threads) && p.Memory <= MAX_MEMORY && p.Threads > 0
+	case SCRYPT:
+		return p.N > 1 && p.N&(p.N-1) == 0 && p.R > 0 && p.P > 0 && p.P <= MAX_SCRYPT_P &&
+			p.N <= MAX_MEMORY*1024/128 && p.R <= MAX_MEMORY*1024/128/p.N
+	case PBKDF2:
+		return p.Iterations > 0 && p.Iterations <= MAX_PBKDF2_ITERATIONS && hash.IsAvailableEngine(p.Engine)
+	default:
+		return false
+	}
+}
+
+// String returns the PHC-like representation of the parameters, eg. `$argon2id$v=19$m=65536,t=3,p=4,l=32$<base64 salt>`
+func (p Parameters) String() string {
+	var params string
+	switch p.Algorithm {
+	case ARGON2ID:
+		params = fmt.Sprintf("v=%d$m=%d,t=%d,p=%d", argon2.Version, p.Memory, p.Time, p.Threads)
+	case SCRYPT:
+		params = fmt.Sprintf("ln=%d,r=%d,p=%d", log2(p.N), p.R, p.P)
+	default:
+		params = fmt.Sprintf("i=%d,h=%s", p.Iterations, p.Engine)
+	}
+	return fmt.Sprintf("$%s$%s,l=%d$%s", p.Algorithm, params, p.KeyLength, base64.RawStdEncoding.EncodeToString(p.Salt))
+}
+
+//--- FUNCTIONS
+
+// DefaultParameters returns the recommended cost parameters for the passed algorithm with a new random salt, ie.
+//   - Argon2id: 3 passes over 64 MiB with 4 threads (RFC 9106);
+//   - scrypt: N=2^15, r=8, p=1;
+//   - PBKDF2: 600,000 iterations of HMAC-SHA-256 (OWASP).
+func DefaultParameters(algorithm Algorithm) (*Parameters, error) {
+	salt := make([]byte, DEFAULT_SALT_LENGTH)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	params := &Parameters{
+		Algorithm: algorithm,
+		Salt:      salt,
+		KeyLength: DEFAULT_KEY_LENGTH,
+	}
+	switch algorithm {
+	case ARGON2ID:
+		params.Time, params.Memory, params.Threads = 3, 64*1024, 4
+	case SCRYPT:
+		params.N, params.R, params.P = 1<<15, 8, 1
+	case PBKDF2:
+		params.Iterations, params.Engine = 600000, hash.SHA_256
+	default:
+		return nil, exception.NewWrongKDFParametersError()
+	}
+	return params, nil
+}
+
+// Parse reads parameters from their String() representation
+func Parse(str string) (*Parameters, error) {
+	parts := strings.Split(str, "$")
+	if len(parts) < 4 || parts[0] != "" {
+		return nil, exception.NewWrongKDFParametersError()
+	}
+	params := &Parameters{
+		Algorithm: Algorithm(parts[1]),
+	}
+	fields := parts[2 : len(parts)-1]
+	if params.Algorithm == ARGON2ID {
+		if len(fields) != 2 || fields[0] != fmt.Sprintf("v=%d", argon2.Version) {
+			return nil, exception.NewWrongKDFParametersError()
+		}
+		fields = fields[1:]
+	}
+	if len(fields) != 1 {
+		return nil, exception.NewWrongKDFParametersError()
+	}
+	for _, field := range strings.Split(fields[0], ",") {
+		name, value, found := strings.Cut(field, "=")
+		if !found {
+			return nil, exception.NewWrongKDFParametersError()
+		}
+		if name == "h" {
+			params.Engine = hash.Engine(value)
+			continue
+		}
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, exception.NewWrongKDFParametersError()
+		}
+		switch {
+		case name == "l":
+			params.KeyLength = int(n)
+		case name == "m" && params.Algorithm == ARGON2ID:
+			params.Memory = uint32(n)
+		case name == "t" && params.Algorithm == ARGON2ID:
+			params.Time = uint32(n)
+		case name == "p" && params.Algorithm == ARGON2ID && n <= math.MaxUint8:
+			params.Threads = uint8(n)
+		case name == "ln" && params.Algorithm == SCRYPT && n < 63:
+			params.N = 1 << n
+		case name == "r" && params.Algorithm == SCRYPT:
+			params.R = int(n)
+		case name == "p" && params.Algorithm == SCRYPT:
+			params.P = int(n)
+		case name == "i" && params.Algorithm == PBKDF2:
+			params.Iterations = int(n)
+		default:
+			return nil, exception.NewWrongKDFParametersError()
+		}
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[len(parts)-1])
+	if err != nil {
+		return nil, exception.NewWrongKDFParametersError()
+	}
+	params.Salt = salt
+	if !params.IsValid() {
+		return nil, exception.NewWrongKDFParametersError()
+	}
+	return params, nil
+}
+
+//--- utilities
+
+func log2(n int) int {
+	l := 0
+	for n > 1 {
+		n >>= 1
+		l++
+	}
+	return l
+}
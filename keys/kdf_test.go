@@ -0,0 +1,135 @@
+package keys_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cyrildever/feistel"
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/exception"
+	"github.com/cyrildever/feistel/keys"
+	"gotest.tools/assert"
+)
+
+// TestDerive ...
+func TestDerive(t *testing.T) {
+	salt := []byte("saltsaltsaltsalt")
+
+	pbkdf2 := keys.Parameters{
+		Algorithm:  keys.PBKDF2,
+		Salt:       salt,
+		KeyLength:  32,
+		Iterations: 1000,
+		Engine:     hash.SHA_256,
+	}
+	found, err := pbkdf2.DeriveKey("passphrase")
+	assert.NilError(t, err)
+	assert.Equal(t, found, "70fde0d598e7c9eb75385fec668c586e7501a4faf4c8cc201230526604674e87")
+
+	scrypt := keys.Parameters{
+		Algorithm: keys.SCRYPT,
+		Salt:      salt,
+		KeyLength: 32,
+		N:         1024,
+		R:         8,
+		P:         1,
+	}
+	found, err = scrypt.DeriveKey("passphrase")
+	assert.NilError(t, err)
+	assert.Equal(t, found, "0ff99cad2a2602cd44b1811a87aa3e4438b1edb7afd4e817e2c79aa6c41423ee")
+
+	argon2id := keys.Parameters{
+		Algorithm: keys.ARGON2ID,
+		Salt:      salt,
+		KeyLength: 32,
+		Time:      1,
+		Memory:    1024,
+		Threads:   1,
+	}
+	first, err := argon2id.DeriveKey("passphrase")
+	assert.NilError(t, err)
+	assert.Equal(t, len(first), 64)
+	second, _ := argon2id.DeriveKey("passphrase")
+	assert.Equal(t, first, second)
+	other, _ := argon2id.DeriveKey("other passphrase")
+	assert.Assert(t, first != other)
+
+	cipher := feistel.NewFPECipher(hash.SHA_256, first, 10)
+	obfuscated, err := cipher.Encrypt("Edgewhere")
+	assert.NilError(t, err)
+	deciphered, err := cipher.Decrypt(obfuscated)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, "Edgewhere")
+
+	_, err = argon2id.Derive("")
+	assert.Error(t, err, exception.NewWrongKDFParametersError().Error())
+
+	wrong := scrypt
+	wrong.N = 1000
+	_, err = wrong.Derive("passphrase")
+	assert.Error(t, err, exception.NewWrongKDFParametersError().Error())
+}
+
+// TestDefaultParameters ...
+func TestDefaultParameters(t *testing.T) {
+	for _, algorithm := range []keys.Algorithm{keys.ARGON2ID, keys.PBKDF2, keys.SCRYPT} {
+		params, err := keys.DefaultParameters(algorithm)
+		assert.NilError(t, err)
+		assert.Assert(t, params.IsValid())
+		assert.Equal(t, len(params.Salt), keys.DEFAULT_SALT_LENGTH)
+		assert.Equal(t, params.KeyLength, keys.DEFAULT_KEY_LENGTH)
+	}
+	first, _ := keys.DefaultParameters(keys.SCRYPT)
+	second, _ := keys.DefaultParameters(keys.SCRYPT)
+	assert.Assert(t, string(first.Salt) != string(second.Salt))
+
+	_, err := keys.DefaultParameters("bcrypt")
+	assert.Error(t, err, exception.NewWrongKDFParametersError().Error())
+}
+
+// TestParse ...
+func TestParse(t *testing.T) {
+	salt := []byte("saltsaltsaltsalt")
+
+	argon2id := keys.Parameters{Algorithm: keys.ARGON2ID, Salt: salt, KeyLength: 32, Time: 3, Memory: 65536, Threads: 4}
+	assert.Equal(t, argon2id.String(), "$argon2id$v=19$m=65536,t=3,p=4,l=32$c2FsdHNhbHRzYWx0c2FsdA")
+	scrypt := keys.Parameters{Algorithm: keys.SCRYPT, Salt: salt, KeyLength: 32, N: 32768, R: 8, P: 1}
+	assert.Equal(t, scrypt.String(), "$scrypt$ln=15,r=8,p=1,l=32$c2FsdHNhbHRzYWx0c2FsdA")
+	pbkdf2 := keys.Parameters{Algorithm: keys.PBKDF2, Salt: salt, KeyLength: 32, Iterations: 600000, Engine: hash.SHA_3}
+	assert.Equal(t, pbkdf2.String(), "$pbkdf2$i=600000,h=sha3-256,l=32$c2FsdHNhbHRzYWx0c2FsdA")
+
+	for _, params := range []keys.Parameters{argon2id, scrypt, pbkdf2} {
+		parsed, err := keys.Parse(params.String())
+		assert.NilError(t, err)
+		assert.DeepEqual(t, *parsed, params)
+
+		serialized, err := json.Marshal(params)
+		assert.NilError(t, err)
+		var unmarshalled keys.Parameters
+		err = json.Unmarshal(serialized, &unmarshalled)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, unmarshalled, params)
+	}
+
+	for _, wrong := range []string{
+		"",
+		"argon2id$v=19$m=65536,t=3,p=4,l=32$c2FsdA",
+		"$argon2id$v=16$m=65536,t=3,p=4,l=32$c2FsdA",
+		"$argon2id$v=19$m=65536,t=3,p=4,ln=15,l=32$c2FsdA",
+		"$scrypt$ln=15,r=8,p=1,l=32$",
+		"$pbkdf2$i=600000,h=md5,l=32$c2FsdA",
+		"$bcrypt$i=10,l=32$c2FsdA",
+		// Tampered costs
+		"$argon2id$v=19$m=4294967295,t=3,p=4,l=32$c2FsdA",
+		"$argon2id$v=19$m=65536,t=4294967295,p=4,l=32$c2FsdA",
+		"$argon2id$v=19$m=65536,t=3,p=257,l=32$c2FsdA",
+		"$argon2id$v=19$m=65536,t=3,p=4,l=4294967295$c2FsdA",
+		"$scrypt$ln=40,r=8,p=1,l=32$c2FsdA",
+		"$scrypt$ln=15,r=4294967295,p=1,l=32$c2FsdA",
+		"$scrypt$ln=15,r=8,p=4294967295,l=32$c2FsdA",
+		"$pbkdf2$i=4294967295,h=sha-256,l=32$c2FsdA",
+	} {
+		_, err := keys.Parse(wrong)
+		assert.Error(t, err, exception.NewWrongKDFParametersError().Error(), wrong)
+	}
+}
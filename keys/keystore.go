@@ -0,0 +1,132 @@
+package keys
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/cyrildever/feistel/exception"
+)
+
+const KEYSTORE_FORMAT = 1
+
+//--- TYPES
+
+// Keystore is a MemoryProvider that may be saved to a local file encrypted with AES-256-GCM,
+// the encryption key being derived from a passphrase with the keystore's derivation `Parameters`.
+// Its zero value is an empty keystore that can only be saved once its `Parameters` are set.
+type Keystore struct {
+	MemoryProvider
+	Parameters Parameters
+}
+
+type keystoreFile struct {
+	Format     int    `json:"format"`
+	KDF        string `json:"kdf"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+//--- METHODS
+
+// Save encrypts the keystore with the passed passphrase and writes it to the passed path, readable by its owner only
+func (ks *Keystore) Save(path, passphrase string) error {
+	params := ks.Parameters
+	params.KeyLength = 32
+	aead, err := newAEAD(params, passphrase)
+	if err != nil {
+		return err
+	}
+	ks.mu.RLock()
+	plaintext, err := json.Marshal(ks.keys)
+	ks.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	file := keystoreFile{
+		Format: KEYSTORE_FORMAT,
+		KDF:    params.String(),
+		Nonce:  make([]byte, aead.NonceSize()),
+	}
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return err
+	}
+	file.Ciphertext = aead.Seal(nil, file.Nonce, plaintext, []byte(file.KDF))
+	content, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	// Write atomically: temporary files are created with 0600 permissions
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".keystore-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+//--- FUNCTIONS
+
+// NewKeystore returns an empty keystore using the passed derivation parameters for its passphrase
+func NewKeystore(params Parameters) *Keystore {
+	return &Keystore{
+		Parameters: params,
+	}
+}
+
+// OpenKeystore reads and decrypts the keystore file at the passed path
+func OpenKeystore(path, passphrase string) (*Keystore, error) {
+	content, err := ReadSecretFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file keystoreFile
+	if err := json.Unmarshal(content, &file); err != nil || file.Format != KEYSTORE_FORMAT {
+		return nil, exception.NewWrongPassphraseError()
+	}
+	params, err := Parse(file.KDF)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(*params, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Nonce) != aead.NonceSize() {
+		return nil, exception.NewWrongPassphraseError()
+	}
+	plaintext, err := aead.Open(nil, file.Nonce, file.Ciphertext, []byte(file.KDF))
+	if err != nil {
+		return nil, exception.NewWrongPassphraseError()
+	}
+	ks := NewKeystore(*params)
+	if err := json.Unmarshal(plaintext, &ks.keys); err != nil {
+		return nil, exception.NewWrongPassphraseError()
+	}
+	return ks, nil
+}
+
+//--- utilities
+
+func newAEAD(params Parameters, passphrase string) (cipher.AEAD, error) {
+	key, err := params.Derive(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,65 @@
+package keys_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/cyrildever/feistel/exception"
+	"github.com/cyrildever/feistel/keys"
+	"gotest.tools/assert"
+)
+
+// TestKeystore ...
+func TestKeystore(t *testing.T) {
+	params, err := keys.DefaultParameters(keys.ARGON2ID)
+	assert.NilError(t, err)
+	params.Time, params.Memory, params.Threads = 1, 1024, 1 // Fast for tests
+
+	ks := keys.NewKeystore(*params)
+	assert.NilError(t, ks.Set("pii", 1, []byte("first-key")))
+	assert.NilError(t, ks.Set("pii", 2, []byte("second-key")))
+	assert.NilError(t, ks.Set("users.email", 1, []byte("email-key")))
+
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	assert.NilError(t, ks.Save(path, "my secret passphrase"))
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		assert.NilError(t, err)
+		assert.Equal(t, info.Mode().Perm(), os.FileMode(0600))
+	}
+
+	opened, err := keys.OpenKeystore(path, "my secret passphrase")
+	assert.NilError(t, err)
+	var provider keys.KeyProvider = opened
+	found, err := provider.Key("pii", keys.LATEST)
+	assert.NilError(t, err)
+	assert.Equal(t, string(found), "second-key")
+	found, err = provider.Key("users.email", 1)
+	assert.NilError(t, err)
+	assert.Equal(t, string(found), "email-key")
+	assert.DeepEqual(t, opened.Parameters.Salt, params.Salt)
+
+	_, err = keys.OpenKeystore(path, "wrong passphrase")
+	assert.Error(t, err, exception.NewWrongPassphraseError().Error())
+
+	if runtime.GOOS != "windows" {
+		assert.NilError(t, os.Chmod(path, 0666))
+		_, err = keys.OpenKeystore(path, "my secret passphrase")
+		assert.Error(t, err, exception.NewUnsafePermissionsError().Error())
+	}
+
+	// The zero value is usable
+	var empty keys.Keystore
+	_, err = empty.Key("pii", keys.LATEST)
+	assert.Error(t, err, exception.NewKeyNotFoundError().Error())
+	assert.NilError(t, empty.Set("pii", 1, []byte("first-key")))
+	found, err = empty.Key("pii", keys.LATEST)
+	assert.NilError(t, err)
+	assert.Equal(t, string(found), "first-key")
+	err = empty.Save(filepath.Join(t.TempDir(), "empty.json"), "my secret passphrase")
+	assert.Error(t, err, exception.NewWrongKDFParametersError().Error())
+	empty.Parameters = *params
+	assert.NilError(t, empty.Save(filepath.Join(t.TempDir(), "empty.json"), "my secret passphrase"))
+}
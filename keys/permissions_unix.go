@@ -0,0 +1,22 @@
+//go:build !windows
+
+package keys
+
+import (
+	"os"
+	"syscall"
+)
+
+// isSafeSecretFile tells whether the file is only accessible to its owner, or read-only for the group and others
+// and owned by the current user or root
+func isSafeSecretFile(info os.FileInfo) bool {
+	perm := info.Mode().Perm()
+	if perm&0077 == 0 {
+		return true
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || perm&0022 != 0 {
+		return false
+	}
+	return stat.Uid == uint32(os.Getuid()) || stat.Uid == 0
+}
@@ -0,0 +1,12 @@
+//go:build windows
+
+package keys
+
+import (
+	"os"
+)
+
+// isSafeSecretFile always accepts the file as Windows permissions aren't reflected by the file mode
+func isSafeSecretFile(info os.FileInfo) bool {
+	return true
+}
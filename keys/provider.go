@@ -0,0 +1,159 @@
+package keys
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cyrildever/feistel/exception"
+)
+
+const (
+	// LATEST asks a provider for the latest version of a key
+	LATEST = 0
+
+	DEFAULT_ENV_PREFIX = "FEISTEL_KEY_"
+)
+
+//--- TYPES
+
+// KeyProvider fetches keys by name and version, versions starting at 1 and LATEST standing for the latest one
+type KeyProvider interface {
+	Key(name string, version int) ([]byte, error)
+}
+
+// EnvProvider reads keys from environment variables named after the `Prefix` and the upper-cased key name,
+// eg. `FEISTEL_KEY_PII` for the latest version of the "pii" key and `FEISTEL_KEY_PII_V2` for its second version.
+// Dashes, dots and slashes in key names are replaced by underscores.
+type EnvProvider struct {
+	Prefix string
+}
+
+// FileProvider reads keys from the files of the `Dir` directory, eg. `<Dir>/pii` for the latest version
+// of the "pii" key and `<Dir>/pii.v2` for its second version, trailing newlines being trimmed.
+// Files that are writable by the group or others, or readable by them but not owned by the current user or root,
+// are rejected (except on Windows, see ReadSecretFile()).
+type FileProvider struct {
+	Dir string
+}
+
+// MemoryProvider holds keys in memory, eg. for tests.
+// It's safe for concurrent use and its zero value is an empty provider.
+type MemoryProvider struct {
+	mu   sync.RWMutex
+	keys map[string]map[int][]byte
+}
+
+//--- METHODS
+
+// Key ...
+func (p EnvProvider) Key(name string, version int) ([]byte, error) {
+	if name == "" || version < 0 {
+		return nil, exception.NewKeyNotFoundError()
+	}
+	prefix := p.Prefix
+	if prefix == "" {
+		prefix = DEFAULT_ENV_PREFIX
+	}
+	variable := prefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_", "/", "_").Replace(name))
+	if version != LATEST {
+		variable = fmt.Sprintf("%s_V%d", variable, version)
+	}
+	value, ok := os.LookupEnv(variable)
+	if !ok || value == "" {
+		return nil, exception.NewKeyNotFoundError()
+	}
+	return []byte(value), nil
+}
+
+// Key ...
+func (p FileProvider) Key(name string, version int) ([]byte, error) {
+	if name == "" || version < 0 || name != filepath.Base(name) {
+		return nil, exception.NewKeyNotFoundError()
+	}
+	path := filepath.Join(p.Dir, name)
+	if version != LATEST {
+		path = fmt.Sprintf("%s.v%d", path, version)
+	}
+	content, err := ReadSecretFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, exception.NewKeyNotFoundError()
+		}
+		return nil, err
+	}
+	key := bytes.TrimRight(content, "\r\n")
+	if len(key) == 0 {
+		return nil, exception.NewKeyNotFoundError()
+	}
+	return key, nil
+}
+
+// Key ...
+func (p *MemoryProvider) Key(name string, version int) ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	versions, ok := p.keys[name]
+	if !ok || version < 0 {
+		return nil, exception.NewKeyNotFoundError()
+	}
+	if version == LATEST {
+		for v := range versions {
+			version = max(version, v)
+		}
+	}
+	key, ok := versions[version]
+	if !ok {
+		return nil, exception.NewKeyNotFoundError()
+	}
+	return bytes.Clone(key), nil
+}
+
+// Set registers a copy of the passed key under its name and version
+func (p *MemoryProvider) Set(name string, version int, key []byte) error {
+	if name == "" || version < 1 || len(key) == 0 {
+		return exception.NewWrongCipherParametersError()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.keys == nil {
+		p.keys = make(map[string]map[int][]byte)
+	}
+	if _, ok := p.keys[name]; !ok {
+		p.keys[name] = make(map[int][]byte)
+	}
+	p.keys[name][version] = bytes.Clone(key)
+	return nil
+}
+
+//--- FUNCTIONS
+
+// NewMemoryProvider ...
+func NewMemoryProvider() *MemoryProvider {
+	return &MemoryProvider{
+		keys: make(map[string]map[int][]byte),
+	}
+}
+
+// ReadSecretFile reads the passed regular file after checking that it's either only accessible to its owner, or read-only
+// for the group and others and owned by the current user or root, like the secrets mounted by Docker or Kubernetes.
+// The checks are made on the opened file itself, so that a symbolic link is only followed to a file that passes them.
+func ReadSecretFile(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() || !isSafeSecretFile(info) {
+		return nil, exception.NewUnsafePermissionsError()
+	}
+	return io.ReadAll(file)
+}
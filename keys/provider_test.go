@@ -0,0 +1,93 @@
+package keys_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/cyrildever/feistel/exception"
+	"github.com/cyrildever/feistel/keys"
+	"gotest.tools/assert"
+)
+
+// TestEnvProvider ...
+func TestEnvProvider(t *testing.T) {
+	t.Setenv("FEISTEL_KEY_USERS_EMAIL", "latest-key")
+	t.Setenv("FEISTEL_KEY_USERS_EMAIL_V2", "second-key")
+	t.Setenv("MY_APP_PII", "prefixed-key")
+
+	var provider keys.KeyProvider = keys.EnvProvider{}
+	found, err := provider.Key("users.email", keys.LATEST)
+	assert.NilError(t, err)
+	assert.Equal(t, string(found), "latest-key")
+	found, err = provider.Key("users.email", 2)
+	assert.NilError(t, err)
+	assert.Equal(t, string(found), "second-key")
+	_, err = provider.Key("users.email", 1)
+	assert.Error(t, err, exception.NewKeyNotFoundError().Error())
+
+	found, err = keys.EnvProvider{Prefix: "MY_APP_"}.Key("pii", keys.LATEST)
+	assert.NilError(t, err)
+	assert.Equal(t, string(found), "prefixed-key")
+}
+
+// TestFileProvider ...
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "pii"), []byte("latest-key\n"), 0600))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "pii.v1"), []byte("first-key"), 0400))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "mounted"), []byte("mounted-key"), 0644))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "shared"), []byte("shared-key"), 0664))
+	assert.NilError(t, os.Chmod(filepath.Join(dir, "shared"), 0664)) // Whatever the umask
+	assert.NilError(t, os.Symlink(filepath.Join(dir, "shared"), filepath.Join(dir, "link")))
+
+	var provider keys.KeyProvider = keys.FileProvider{Dir: dir}
+	found, err := provider.Key("pii", keys.LATEST)
+	assert.NilError(t, err)
+	assert.Equal(t, string(found), "latest-key")
+	found, err = provider.Key("pii", 1)
+	assert.NilError(t, err)
+	assert.Equal(t, string(found), "first-key")
+
+	_, err = provider.Key("pii", 2)
+	assert.Error(t, err, exception.NewKeyNotFoundError().Error())
+	_, err = provider.Key("../pii", keys.LATEST)
+	assert.Error(t, err, exception.NewKeyNotFoundError().Error())
+	if runtime.GOOS != "windows" {
+		// Read-only for the group and others, and owned by the current user, like Docker or Kubernetes secrets
+		found, err = provider.Key("mounted", keys.LATEST)
+		assert.NilError(t, err)
+		assert.Equal(t, string(found), "mounted-key")
+
+		_, err = provider.Key("shared", keys.LATEST)
+		assert.Error(t, err, exception.NewUnsafePermissionsError().Error())
+		_, err = provider.Key("link", keys.LATEST)
+		assert.Error(t, err, exception.NewUnsafePermissionsError().Error())
+		_, err = provider.Key(".", keys.LATEST)
+		assert.Error(t, err, exception.NewUnsafePermissionsError().Error())
+	}
+}
+
+// TestMemoryProvider ...
+func TestMemoryProvider(t *testing.T) {
+	provider := keys.NewMemoryProvider()
+	key := []byte("first-key")
+	assert.NilError(t, provider.Set("pii", 1, key))
+	assert.NilError(t, provider.Set("pii", 3, []byte("third-key")))
+	key[0] = 'F' // Keys are copied
+
+	found, err := provider.Key("pii", 1)
+	assert.NilError(t, err)
+	assert.Equal(t, string(found), "first-key")
+	found, err = provider.Key("pii", keys.LATEST)
+	assert.NilError(t, err)
+	assert.Equal(t, string(found), "third-key")
+	_, err = provider.Key("pii", 2)
+	assert.Error(t, err, exception.NewKeyNotFoundError().Error())
+	_, err = provider.Key("unknown", keys.LATEST)
+	assert.Error(t, err, exception.NewKeyNotFoundError().Error())
+
+	err = provider.Set("pii", keys.LATEST, key)
+	assert.Error(t, err, exception.NewWrongCipherParametersError().Error())
+}
@@ -0,0 +1,64 @@
+package keys
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cyrildever/feistel/exception"
+)
+
+const (
+	ENV_REFERENCE      = "env:"
+	FILE_REFERENCE     = "file:"
+	PROVIDER_REFERENCE = "provider:"
+)
+
+//--- FUNCTIONS
+
+// Resolve returns the secret of the passed key reference, ie.
+//   - `env:NAME` for the value of the NAME environment variable;
+//   - `file:PATH` for the content of the file at PATH, trailing newlines being trimmed (see ReadSecretFile());
+//   - `provider:NAME` or `provider:NAME@VERSION` for the latest or the passed version of the key from the passed provider.
+//
+// Inline keys are rejected with an UnknownKeyReferenceError.
+func Resolve(reference string, provider KeyProvider) (*Secret, error) {
+	switch {
+	case strings.HasPrefix(reference, ENV_REFERENCE):
+		value, ok := os.LookupEnv(strings.TrimPrefix(reference, ENV_REFERENCE))
+		if !ok || value == "" {
+			return nil, exception.NewKeyNotFoundError()
+		}
+		return NewSecret([]byte(value)), nil
+	case strings.HasPrefix(reference, FILE_REFERENCE):
+		content, err := ReadSecretFile(strings.TrimPrefix(reference, FILE_REFERENCE))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, exception.NewKeyNotFoundError()
+			}
+			return nil, err
+		}
+		defer Zeroize(content)
+		key := bytes.TrimRight(content, "\r\n")
+		if len(key) == 0 {
+			return nil, exception.NewKeyNotFoundError()
+		}
+		return NewSecret(key), nil
+	case strings.HasPrefix(reference, PROVIDER_REFERENCE):
+		if provider == nil {
+			return nil, exception.NewKeyNotFoundError()
+		}
+		name, version := strings.TrimPrefix(reference, PROVIDER_REFERENCE), LATEST
+		if at := strings.LastIndex(name, "@"); at != -1 {
+			v, err := strconv.Atoi(name[at+1:])
+			if err != nil || v < 1 {
+				return nil, exception.NewUnknownKeyReferenceError()
+			}
+			name, version = name[:at], v
+		}
+		return FetchSecret(provider, name, version)
+	default:
+		return nil, exception.NewUnknownKeyReferenceError()
+	}
+}
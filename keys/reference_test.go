@@ -0,0 +1,46 @@
+package keys_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cyrildever/feistel/exception"
+	"github.com/cyrildever/feistel/keys"
+	"gotest.tools/assert"
+)
+
+// TestResolve ...
+func TestResolve(t *testing.T) {
+	t.Setenv("MY_KEY", "env-key")
+	path := filepath.Join(t.TempDir(), "key")
+	assert.NilError(t, os.WriteFile(path, []byte("file-key\r\n"), 0600))
+	provider := keys.NewMemoryProvider()
+	assert.NilError(t, provider.Set("pii", 1, []byte("first-key")))
+	assert.NilError(t, provider.Set("pii", 2, []byte("second-key")))
+
+	for reference, expected := range map[string]string{
+		"env:MY_KEY":     "env-key",
+		"file:" + path:   "file-key",
+		"provider:pii":   "second-key",
+		"provider:pii@1": "first-key",
+	} {
+		secret, err := keys.Resolve(reference, provider)
+		assert.NilError(t, err)
+		assert.Equal(t, string(secret.Bytes()), expected)
+	}
+
+	for reference, expected := range map[string]error{
+		"my-inline-key":        exception.NewUnknownKeyReferenceError(),
+		"provider:pii@latest":  exception.NewUnknownKeyReferenceError(),
+		"env:MY_MISSING_KEY":   exception.NewKeyNotFoundError(),
+		"file:" + path + ".v2": exception.NewKeyNotFoundError(),
+		"provider:pii@3":       exception.NewKeyNotFoundError(),
+		"provider:unknown":     exception.NewKeyNotFoundError(),
+	} {
+		_, err := keys.Resolve(reference, provider)
+		assert.Error(t, err, expected.Error(), reference)
+	}
+	_, err := keys.Resolve("provider:pii", nil)
+	assert.Error(t, err, exception.NewKeyNotFoundError().Error())
+}
@@ -0,0 +1,91 @@
+package keys
+
+import (
+	"fmt"
+	"io"
+)
+
+const REDACTED = "[REDACTED]"
+
+//--- TYPES
+
+// Secret holds a key in a byte slice that may be wiped from memory with Destroy(),
+// and that is never printed by the fmt package nor marshalled to JSON.
+// NB: It's not safe to destroy a secret while it's being used.
+type Secret struct {
+	key []byte
+}
+
+//--- METHODS
+
+// Bytes returns the underlying key, which must neither be modified nor retained, or nil if the secret was destroyed
+func (s *Secret) Bytes() []byte {
+	if s == nil {
+		return nil
+	}
+	return s.key
+}
+
+// Destroy overwrites the key with zeros and releases it
+func (s *Secret) Destroy() {
+	if s == nil {
+		return
+	}
+	clear(s.key)
+	s.key = nil
+}
+
+// IsDestroyed ...
+func (s *Secret) IsDestroyed() bool {
+	return s == nil || s.key == nil
+}
+
+// Len ...
+func (s *Secret) Len() int {
+	return len(s.Bytes())
+}
+
+// Format implements fmt.Formatter so that the key never gets printed, whatever the verb, be the secret a pointer or a copy
+func (s Secret) Format(f fmt.State, verb rune) {
+	_, _ = io.WriteString(f, REDACTED)
+}
+
+// GoString ...
+func (s Secret) GoString() string {
+	return REDACTED
+}
+
+// MarshalJSON ...
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + REDACTED + `"`), nil
+}
+
+// String ...
+func (s Secret) String() string {
+	return REDACTED
+}
+
+//--- FUNCTIONS
+
+// NewSecret returns a secret holding a copy of the passed key, which should then be wiped by the caller (see Zeroize())
+func NewSecret(key []byte) *Secret {
+	return &Secret{
+		key: append(make([]byte, 0, len(key)), key...),
+	}
+}
+
+// FetchSecret returns the passed key from the provider as a secret, without leaving any copy behind
+func FetchSecret(provider KeyProvider, name string, version int) (*Secret, error) {
+	key, err := provider.Key(name, version)
+	if err != nil {
+		return nil, err
+	}
+	return &Secret{
+		key: key,
+	}, nil
+}
+
+// Zeroize overwrites the passed byte slice with zeros
+func Zeroize(b []byte) {
+	clear(b)
+}
@@ -0,0 +1,54 @@
+package keys_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/cyrildever/feistel/keys"
+	"gotest.tools/assert"
+)
+
+// TestSecret ...
+func TestSecret(t *testing.T) {
+	key := []byte("some-32-byte-long-key-to-be-safe")
+	secret := keys.NewSecret(key)
+	keys.Zeroize(key)
+	assert.DeepEqual(t, key, make([]byte, 32))
+	assert.Equal(t, string(secret.Bytes()), "some-32-byte-long-key-to-be-safe")
+	assert.Equal(t, secret.Len(), 32)
+
+	for _, format := range []string{"%s", "%v", "%+v", "%#v", "%x", "%q"} {
+		assert.Equal(t, fmt.Sprintf(format, secret), keys.REDACTED, format)
+		assert.Equal(t, fmt.Sprintf(format, *secret), keys.REDACTED, format) // Copies are redacted too
+	}
+	assert.Equal(t, fmt.Sprintf("%+v", struct{ Key *keys.Secret }{secret}), "{Key:[REDACTED]}")
+	assert.Equal(t, fmt.Sprintf("%#v", struct{ Key keys.Secret }{*secret}), "struct { Key keys.Secret }{Key:[REDACTED]}")
+	assert.Equal(t, secret.String(), keys.REDACTED)
+	assert.Equal(t, secret.GoString(), keys.REDACTED)
+	serialized, err := json.Marshal(map[string]*keys.Secret{"key": secret})
+	assert.NilError(t, err)
+	assert.Equal(t, string(serialized), `{"key":"[REDACTED]"}`)
+
+	underlying := secret.Bytes()
+	secret.Destroy()
+	assert.Assert(t, secret.IsDestroyed())
+	assert.DeepEqual(t, underlying, make([]byte, 32))
+	assert.Assert(t, secret.Bytes() == nil)
+	assert.Equal(t, secret.Len(), 0)
+
+	var none *keys.Secret
+	assert.Assert(t, none.IsDestroyed())
+	none.Destroy()
+}
+
+// TestFetchSecret ...
+func TestFetchSecret(t *testing.T) {
+	provider := keys.NewMemoryProvider()
+	assert.NilError(t, provider.Set("pii", 1, []byte("first-key")))
+	secret, err := keys.FetchSecret(provider, "pii", keys.LATEST)
+	assert.NilError(t, err)
+	assert.Equal(t, string(secret.Bytes()), "first-key")
+	_, err = keys.FetchSecret(provider, "unknown", keys.LATEST)
+	assert.Assert(t, err != nil)
+}
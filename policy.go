@@ -0,0 +1,269 @@
+package feistel
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/exception"
+	"github.com/cyrildever/feistel/keys"
+)
+
+//--- TYPES
+
+// Policy defines the requirements enforced by the validating constructors, meant to be shared between teams (eg. as a JSON file).
+// Zero values disable the corresponding checks.
+type Policy struct {
+	MinKeyLength          int           `json:"minKeyLength,omitempty"`  // In bytes
+	MinKeyEntropy         float64       `json:"minKeyEntropy,omitempty"` // In bits, as estimated by EstimateEntropy()
+	MinCipherRounds       int           `json:"minCipherRounds,omitempty"`
+	MinCustomCipherRounds int           `json:"minCustomCipherRounds,omitempty"` // ie. the minimum number of keys
+	MinFPECipherRounds    int           `json:"minFPECipherRounds,omitempty"`
+	ForbiddenEngines      []hash.Engine `json:"forbiddenEngines,omitempty"`
+
+	// StrictWarnings turns warnings into errors
+	StrictWarnings bool `json:"strictWarnings,omitempty"`
+	// Warn, if set, is called with each warning raised on keys
+	Warn func(warning Warning) `json:"-"`
+}
+
+// Warning is raised for keys that are long enough but look weak
+type Warning string
+
+const (
+	DICTIONARY_WORD_WARNING Warning = "key looks like dictionary words"
+	LOW_ENTROPY_HEX_WARNING Warning = "key looks like low-entropy hexadecimal"
+)
+
+var (
+	// DefaultPolicy requires keys of at least 16 bytes with an estimated entropy of 128 bits (eg. the 32 hexadecimal
+	// characters of a random 128-bit key), 10 rounds for the Cipher and the FPECipher, and 4 keys for the CustomCipher
+	DefaultPolicy = Policy{
+		MinKeyLength:          16,
+		MinKeyEntropy:         128,
+		MinCipherRounds:       10,
+		MinCustomCipherRounds: 4,
+		MinFPECipherRounds:    10,
+	}
+
+	weakWords = []string{
+		"123456", "abc123", "admin", "changeme", "default", "dragon", "example", "iloveyou", "letmein", "master",
+		"monkey", "passw", "qwerty", "azerty", "secret", "sunshine", "test", "welcome",
+	}
+)
+
+//--- METHODS
+
+// CheckEngine ...
+func (p Policy) CheckEngine(engine hash.Engine) error {
+	if !hash.IsAvailableEngine(engine) {
+		return exception.NewUnkownEngineError()
+	}
+	for _, forbidden := range p.ForbiddenEngines {
+		if engine == forbidden {
+			return exception.NewForbiddenEngineError()
+		}
+	}
+	return nil
+}
+
+// CheckKey returns a WeakKeyError if the passed key is too short or doesn't hold enough estimated entropy,
+// warnings being raised (or returned as errors if strict) for dictionary words and low-entropy hexadecimal
+func (p Policy) CheckKey(key string) error {
+	return p.checkKey([]byte(key))
+}
+
+// checkKey is the CheckKey() method working on the bytes of the key, so that no copy of a secret key is left in a string
+func (p Policy) checkKey(key []byte) error {
+	if len(key) == 0 || len(key) < p.MinKeyLength || estimateEntropy(key) < p.MinKeyEntropy {
+		return exception.NewWeakKeyError()
+	}
+	for _, warning := range inspect(key) {
+		if p.StrictWarnings {
+			return exception.NewWeakKeyError()
+		}
+		if p.Warn != nil {
+			p.Warn(warning)
+		}
+	}
+	return nil
+}
+
+func (p Policy) check(engine hash.Engine, keys [][]byte, rounds, minRounds int) error {
+	if err := p.CheckEngine(engine); err != nil {
+		return err
+	}
+	if rounds < 2 || rounds < minRounds {
+		return exception.NewTooFewRoundsError()
+	}
+	for _, key := range keys {
+		if err := p.checkKey(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//--- FUNCTIONS
+
+// NewValidatedCipher returns a Cipher after checking its parameters against the passed policy
+func NewValidatedCipher(policy Policy, engine hash.Engine, key string, rounds int) (*Cipher, error) {
+	if err := policy.check(engine, [][]byte{[]byte(key)}, rounds, policy.MinCipherRounds); err != nil {
+		return nil, err
+	}
+	return NewCipherWithEngine(engine, key, rounds), nil
+}
+
+// NewValidatedCustomCipher returns a CustomCipher after checking its parameters against the passed policy
+func NewValidatedCustomCipher(policy Policy, engine hash.Engine, keys []string) (*CustomCipher, error) {
+	roundKeys := make([][]byte, len(keys))
+	for i, key := range keys {
+		roundKeys[i] = []byte(key)
+	}
+	if err := policy.check(engine, roundKeys, len(keys), policy.MinCustomCipherRounds); err != nil {
+		return nil, err
+	}
+	return NewCustomCipherWithEngine(engine, keys), nil
+}
+
+// NewValidatedFPECipher returns an FPECipher after checking its parameters against the passed policy
+func NewValidatedFPECipher(policy Policy, engine hash.Engine, key string, rounds int) (*FPECipher, error) {
+	if err := policy.check(engine, [][]byte{[]byte(key)}, rounds, policy.MinFPECipherRounds); err != nil {
+		return nil, err
+	}
+	return NewFPECipher(engine, key, rounds), nil
+}
+
+// EstimateEntropy returns a rough estimate of the entropy of the passed key in bits, ie. the length of its shortest
+// repeated pattern times the lowest of the empirical Shannon entropy of its characters and the size of their classes.
+// As the empirical entropy of a short sample underestimates that of its source, hexadecimal characters count for
+// their full 4 bits unless their distribution is obviously skewed.
+func EstimateEntropy(key string) float64 {
+	return estimateEntropy([]byte(key))
+}
+
+// Inspect returns the warnings raised by the passed key
+func Inspect(key string) []Warning {
+	return inspect([]byte(key))
+}
+
+//--- utilities
+
+func estimateEntropy(key []byte) float64 {
+	pattern := period(key)
+	if len(pattern) == 0 {
+		return 0
+	}
+	if isHex(pattern) {
+		lower := bytes.ToLower(pattern) // The case of hexadecimal characters is irrelevant
+		defer keys.Zeroize(lower)
+		entropy := shannon(lower)
+		if entropy > 0 && entropy >= 0.625*math.Min(4, math.Log2(float64(len(pattern)))) {
+			return 4 * float64(len(pattern))
+		}
+		return entropy * float64(len(pattern))
+	}
+	// The size of each class of characters, indexed by its first character
+	classes := make(map[rune]float64)
+	for _, char := range string(pattern) { // No allocation
+		switch {
+		case char > unicode.MaxASCII:
+			classes[unicode.MaxASCII+1] = 128
+		case unicode.IsLower(char):
+			classes['a'] = 26
+		case unicode.IsUpper(char):
+			classes['A'] = 26
+		case unicode.IsDigit(char):
+			classes['0'] = 10
+		default:
+			classes['!'] = 33
+		}
+	}
+	pool := 0.
+	for _, size := range classes {
+		pool += size
+	}
+	return float64(len(pattern)) * math.Min(math.Log2(pool), shannon(pattern))
+}
+
+func inspect(key []byte) (warnings []Warning) {
+	if isWords(key) {
+		warnings = append(warnings, DICTIONARY_WORD_WARNING)
+	}
+	if len(key) >= 16 && isHex(key) && estimateEntropy(key) < 2*float64(len(key)) {
+		warnings = append(warnings, LOW_ENTROPY_HEX_WARNING)
+	}
+	return
+}
+
+func isHex(str []byte) bool {
+	for _, char := range str {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", rune(char)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isWords tells whether the passed string contains a common weak word, or is a passphrase, ie. only made of letters
+// and separators with at least one separator between letters (a random string of letters isn't made of words)
+func isWords(str []byte) bool {
+	lower := bytes.ToLower(str)
+	defer keys.Zeroize(lower)
+	for _, word := range weakWords {
+		if bytes.Contains(lower, []byte(word)) {
+			return true
+		}
+	}
+	words := bytes.FieldsFunc(lower, func(char rune) bool {
+		return strings.ContainsRune(" -_.", char)
+	})
+	if len(words) < 2 {
+		return false
+	}
+	for _, word := range words {
+		for _, char := range string(word) { // No allocation
+			if !unicode.IsLetter(char) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// period returns the shortest pattern whose repetition makes the passed string
+func period(str []byte) []byte {
+	for size := 1; size <= len(str)/2; size++ {
+		if len(str)%size == 0 && isRepeated(str, size) {
+			return str[:size]
+		}
+	}
+	return str
+}
+
+// isRepeated tells whether the passed string is the repetition of its first `size` bytes
+func isRepeated(str []byte, size int) bool {
+	for i := size; i < len(str); i++ {
+		if str[i] != str[i-size] {
+			return false
+		}
+	}
+	return true
+}
+
+func shannon(str []byte) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, char := range string(str) { // No allocation
+		counts[char]++
+		total++
+	}
+	entropy := 0.
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,137 @@
+package feistel_test
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/cyrildever/feistel"
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/exception"
+	"gotest.tools/assert"
+)
+
+// TestEstimateEntropy ...
+func TestEstimateEntropy(t *testing.T) {
+	assert.Equal(t, feistel.EstimateEntropy(""), 0.)
+	assert.Equal(t, feistel.EstimateEntropy("aaaaaaaaaaaaaaaa"), 0.)
+	assert.Equal(t, feistel.EstimateEntropy(katKeys[0]), 64.) // 16 distinct hexadecimal characters repeated
+	assert.Assert(t, feistel.EstimateEntropy(katKey) > 200)
+	assert.Assert(t, feistel.EstimateEntropy(katShortKey) > 100)
+	assert.Assert(t, feistel.EstimateEntropy("a") < feistel.EstimateEntropy("ab"))
+
+	// Each case is a class of its own, except for hexadecimal characters
+	mixed := "qWeRtYuIoPaSdFgHjKlZxCvBnMQwErTyUiOpAsDfGhJkLzXcVbNm"
+	assert.Equal(t, feistel.EstimateEntropy(mixed), 52*math.Log2(52))
+	assert.Assert(t, feistel.EstimateEntropy(mixed) > feistel.EstimateEntropy(strings.ToLower(mixed)))
+	assert.Equal(t, feistel.EstimateEntropy(strings.ToUpper(katKeys[0])), 64.)
+}
+
+// TestInspect ...
+func TestInspect(t *testing.T) {
+	assert.Equal(t, len(feistel.Inspect(katKey)), 0)
+	assert.Equal(t, len(feistel.Inspect(katShortKey)), 0)
+	assert.DeepEqual(t, feistel.Inspect("correct-horse-battery-staple"), []feistel.Warning{feistel.DICTIONARY_WORD_WARNING})
+	assert.DeepEqual(t, feistel.Inspect("MySuperSecret2024!"), []feistel.Warning{feistel.DICTIONARY_WORD_WARNING})
+	assert.DeepEqual(t, feistel.Inspect(katKeys[1]), []feistel.Warning{feistel.LOW_ENTROPY_HEX_WARNING})
+	assert.Equal(t, len(feistel.Inspect("qzmxkvbtrplwjhgfdsnc")), 0) // Random letters aren't words
+}
+
+// TestPolicyWithRandomKeys ...
+func TestPolicyWithRandomKeys(t *testing.T) {
+	randomKey := func() string {
+		key := make([]byte, 16)
+		_, err := rand.Read(key)
+		assert.NilError(t, err)
+		return hex.EncodeToString(key)
+	}
+	for i := 0; i < 1000; i++ {
+		key := randomKey()
+		assert.Assert(t, feistel.EstimateEntropy(key) >= 128, key)
+		assert.Equal(t, len(feistel.Inspect(key)), 0, key)
+		_, err := feistel.NewValidatedCipher(feistel.DefaultPolicy, hash.SHA_256, key, 10)
+		assert.NilError(t, err, key)
+		_, err = feistel.NewValidatedFPECipher(feistel.DefaultPolicy, hash.SHA_256, key, 10)
+		assert.NilError(t, err, key)
+		_, err = feistel.NewValidatedCustomCipher(feistel.DefaultPolicy, hash.SHA_256, []string{key, randomKey(), randomKey(), randomKey()})
+		assert.NilError(t, err, key)
+	}
+
+	// The round keys of a generated CustomCipher too
+	generated, err := feistel.GenerateCustomCipher(4, 16)
+	assert.NilError(t, err)
+	exported, err := generated.ExportKeys()
+	assert.NilError(t, err)
+	var set feistel.KeySet
+	assert.NilError(t, json.Unmarshal(exported, &set))
+	_, err = feistel.NewValidatedCustomCipher(feistel.DefaultPolicy, set.Engine, set.Keys)
+	assert.NilError(t, err)
+}
+
+// TestPolicy ...
+func TestPolicy(t *testing.T) {
+	cipher, err := feistel.NewValidatedCipher(feistel.DefaultPolicy, hash.SHA_256, katKey, 10)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *cipher, *feistel.NewCipher(katKey, 10))
+
+	fpe, err := feistel.NewValidatedFPECipher(feistel.DefaultPolicy, hash.BLAKE2b, katKey, 128)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *fpe, *feistel.NewFPECipher(hash.BLAKE2b, katKey, 128))
+
+	_, err = feistel.NewValidatedFPECipher(feistel.DefaultPolicy, hash.SHA_256, "a", 2)
+	assert.Error(t, err, exception.NewTooFewRoundsError().Error())
+	_, err = feistel.NewValidatedFPECipher(feistel.DefaultPolicy, hash.SHA_256, "a", 10)
+	assert.Error(t, err, exception.NewWeakKeyError().Error())
+	_, err = feistel.NewValidatedFPECipher(feistel.Policy{}, hash.SHA_256, "a", 2)
+	assert.NilError(t, err)
+	_, err = feistel.NewValidatedFPECipher(feistel.Policy{}, hash.SHA_256, "", 2)
+	assert.Error(t, err, exception.NewWeakKeyError().Error())
+	_, err = feistel.NewValidatedFPECipher(feistel.Policy{}, hash.SHA_256, "a", 1)
+	assert.Error(t, err, exception.NewTooFewRoundsError().Error())
+	_, err = feistel.NewValidatedFPECipher(feistel.Policy{}, "unknown", "a", 2)
+	assert.Error(t, err, exception.NewUnkownEngineError().Error())
+
+	// Low-entropy keys
+	_, err = feistel.NewValidatedCustomCipher(feistel.DefaultPolicy, hash.SHA_256, append(katKeys, katKey))
+	assert.Error(t, err, exception.NewWeakKeyError().Error())
+
+	// Warnings
+	var warnings []feistel.Warning
+	policy := feistel.Policy{
+		MinKeyLength:          16,
+		MinCustomCipherRounds: 3,
+		Warn: func(warning feistel.Warning) {
+			warnings = append(warnings, warning)
+		},
+	}
+	custom, err := feistel.NewValidatedCustomCipher(policy, hash.SHA_256, katKeys)
+	assert.NilError(t, err)
+	assert.Equal(t, len(custom.Keys), 3)
+	assert.DeepEqual(t, warnings, []feistel.Warning{
+		feistel.DICTIONARY_WORD_WARNING, feistel.LOW_ENTROPY_HEX_WARNING, // "123456" sequence
+		feistel.LOW_ENTROPY_HEX_WARNING,
+		feistel.DICTIONARY_WORD_WARNING, feistel.LOW_ENTROPY_HEX_WARNING,
+	})
+	policy.StrictWarnings = true
+	_, err = feistel.NewValidatedCustomCipher(policy, hash.SHA_256, katKeys)
+	assert.Error(t, err, exception.NewWeakKeyError().Error())
+	_, err = feistel.NewValidatedCustomCipher(policy, hash.SHA_256, katKeys[:2])
+	assert.Error(t, err, exception.NewTooFewRoundsError().Error())
+
+	// Forbidden engines
+	policy = feistel.DefaultPolicy
+	policy.ForbiddenEngines = []hash.Engine{hash.KECCAK}
+	_, err = feistel.NewValidatedCipher(policy, hash.KECCAK, katKey, 10)
+	assert.Error(t, err, exception.NewForbiddenEngineError().Error())
+
+	// Sharing
+	serialized, err := json.Marshal(policy)
+	assert.NilError(t, err)
+	assert.Equal(t, string(serialized), `{"minKeyLength":16,"minKeyEntropy":128,"minCipherRounds":10,"minCustomCipherRounds":4,"minFPECipherRounds":10,"forbiddenEngines":["keccak-256"]}`)
+	var shared feistel.Policy
+	assert.NilError(t, json.Unmarshal(serialized, &shared))
+	assert.DeepEqual(t, shared.ForbiddenEngines, policy.ForbiddenEngines)
+}
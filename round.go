@@ -0,0 +1,267 @@
+package feistel
+
+import (
+	"crypto/aes"
+	"crypto/sha256"
+	"encoding/binary"
+	"unicode/utf8"
+
+	"github.com/cyrildever/feistel/common/utils"
+	"github.com/cyrildever/feistel/common/utils/cmac"
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/keys"
+	utls "github.com/cyrildever/go-utls/common/utils"
+)
+
+//--- TYPES
+
+// Round defines the pseudo-random function applied at each round of the Feistel network
+type Round string
+
+const (
+	// LEGACY_ROUND hashes the byte-wise sum of the item and the extracted key, ie. H(Add(item, Extract(key, index, len(item)))),
+	// the output of extendable-output engines being sized to cover the whole item
+	LEGACY_ROUND Round = ""
+	// HMAC_ROUND computes HMAC(key, index || len(item) || item) with the round index and the item length as 32-bit
+	// big-endian integers, using the native keyed mode of BLAKE2b when it's the engine
+	HMAC_ROUND Round = "hmac"
+	// AES_ROUND computes AES-CMAC(SHA-256(key), index || len(item) || item) with the same domain separation as HMAC_ROUND,
+	// thus ignoring the hash engine of the cipher but benefiting from hardware acceleration.
+	// Its 16-byte output is always expanded with COUNTER_EXPANSION_V1 (whatever the cipher's expansion) as repeating
+	// it would make the mask periodic
+	AES_ROUND Round = "aes-cmac"
+)
+
+// Expansion defines how the output of the round function is stretched to the length of the processed half
+type Expansion string
+
+const (
+	// REPEAT_EXPANSION extracts the hexadecimal digest from the round index, repeating it if the half is longer (legacy)
+	REPEAT_EXPANSION Expansion = ""
+	// COUNTER_EXPANSION_V1 concatenates HKDF-Expand-like blocks T(c) = H(c || digest) until the half is covered, the counter c
+	// being a 32-bit big-endian integer starting at 1 and H being the engine of the cipher (or AES-CMAC with AES_ROUND)
+	COUNTER_EXPANSION_V1 Expansion = "counter-v1"
+)
+
+// Mask defines how the output of the round function is rendered before being XORed with the other half
+type Mask string
+
+const (
+	// HEX_MASK uses the hexadecimal characters of the digest, ie. only the 16 byte values of `0-9a-f` (legacy)
+	HEX_MASK Mask = ""
+	// RAW_MASK_V1 uses the raw bytes of the digest, always expanded to the length of the half with COUNTER_EXPANSION_V1
+	// (whatever the cipher's expansion) as repeating the digest would make the mask periodic
+	RAW_MASK_V1 Mask = "raw-v1"
+)
+
+// network holds the round function of a cipher for the duration of an encryption or a decryption,
+// so that the key-dependent material is only prepared once and then wiped with destroy()
+type network struct {
+	function  Round
+	expansion Expansion
+	mask      Mask
+	engine    hash.Engine
+	schedule  KeySchedule
+	keys      map[int][]byte
+	macs      map[int]*cmac.CMAC
+}
+
+//--- METHODS
+
+// IsValid ...
+func (e Expansion) IsValid() bool {
+	return e == REPEAT_EXPANSION || e == COUNTER_EXPANSION_V1
+}
+
+// IsValid ...
+func (m Mask) IsValid() bool {
+	return m == HEX_MASK || m == RAW_MASK_V1
+}
+
+// IsValid ...
+func (r Round) IsValid() bool {
+	return r == LEGACY_ROUND || r == HMAC_ROUND || r == AES_ROUND
+}
+
+// round returns the mask of the item's length computed by the round function at the passed index
+func (n *network) round(item string, index int) (string, error) {
+	hashed, err := n.digest(item, index)
+	if err != nil {
+		return "", err
+	}
+	if n.expansion == COUNTER_EXPANSION_V1 || n.mask == RAW_MASK_V1 || n.function == AES_ROUND {
+		expanded, err := n.expand(hashed, index, n.size(len(item)))
+		if err != nil {
+			return "", err
+		}
+		if n.mask == RAW_MASK_V1 {
+			return string(expanded), nil
+		}
+		return utls.ToHex(expanded)[:len(item)], nil
+	}
+	hexHashed := utls.ToHex(hashed)
+	return utils.Extract(hexHashed, index, len(item)), nil
+}
+
+// digest returns the raw output of the round function applied to the passed item at the passed index
+func (n *network) digest(item string, index int) (hashed []byte, err error) {
+	switch n.function {
+	case HMAC_ROUND:
+		key, e := n.key(index)
+		if e != nil {
+			return nil, e
+		}
+		return hash.HMAC(separate(item, index), key, n.engine)
+	case AES_ROUND:
+		mac, e := n.cmac(index)
+		if e != nil {
+			return nil, e
+		}
+		return mac.Sum(separate(item, index)), nil
+	default:
+		key, e := n.key(index)
+		if e != nil {
+			return nil, e
+		}
+		addition := add(item, key, index)
+		defer keys.Zeroize(addition)
+		return hash.HN(addition, n.engine, n.size(len(item)))
+	}
+}
+
+// numbers returns `count` 32-bit unsigned integers read from the digest of the passed item at the passed round index,
+// the item being repeated if it's too short to feed the digest.
+// NB: The digest is always expanded in counter mode (whatever the expansion and the mask of the cipher) as repeating
+// its hexadecimal characters would make the numbers periodic.
+func (n *network) numbers(item string, index, count int) ([]uint32, error) {
+	hashed, err := n.digest(utils.Extract(item, 0, max(len(item), 8*count)), index)
+	if err != nil {
+		return nil, err
+	}
+	expanded, err := n.expand(hashed, index, 4*count)
+	if err != nil {
+		return nil, err
+	}
+	numbers := make([]uint32, count)
+	for i := range numbers {
+		numbers[i] = binary.BigEndian.Uint32(expanded[4*i:])
+	}
+	return numbers, nil
+}
+
+// size returns the number of bytes of digest needed to mask an item of the passed length
+func (n *network) size(length int) int {
+	if n.mask == RAW_MASK_V1 {
+		return length
+	}
+	return (length + 1) / 2
+}
+
+// expand derives `size` bytes from the passed digest in counter mode
+func (n *network) expand(digest []byte, index, size int) ([]byte, error) {
+	expanded := make([]byte, 0, size)
+	block := make([]byte, 4+len(digest))
+	copy(block[4:], digest)
+	for counter := uint32(1); len(expanded) < size; counter++ {
+		binary.BigEndian.PutUint32(block, counter)
+		if n.function == AES_ROUND {
+			mac, err := n.cmac(index)
+			if err != nil {
+				return nil, err
+			}
+			expanded = append(expanded, mac.Sum(block)...)
+		} else {
+			h, err := hash.H(block, n.engine)
+			if err != nil {
+				return nil, err
+			}
+			expanded = append(expanded, h...)
+		}
+	}
+	return expanded[:size], nil
+}
+
+// destroy wipes the round keys held by the network, which mustn't be used afterwards
+func (n *network) destroy() {
+	for slot, key := range n.keys {
+		keys.Zeroize(key)
+		delete(n.keys, slot)
+	}
+	clear(n.macs)
+}
+
+// key returns the key to use at the passed round index according to the key schedule
+func (n *network) key(index int) ([]byte, error) {
+	slot := n.slot(index)
+	if key, ok := n.keys[slot]; ok {
+		return key, nil
+	}
+	key, err := n.schedule.RoundKey(index)
+	if err != nil {
+		return nil, err
+	}
+	n.keys[slot] = key
+	return key, nil
+}
+
+func (n *network) cmac(index int) (*cmac.CMAC, error) {
+	slot := n.slot(index)
+	if mac, ok := n.macs[slot]; ok {
+		return mac, nil
+	}
+	key, err := n.key(index)
+	if err != nil {
+		return nil, err
+	}
+	aesKey := sha256.Sum256(key)
+	defer keys.Zeroize(aesKey[:])
+	block, err := aes.NewCipher(aesKey[:])
+	if err != nil {
+		return nil, err
+	}
+	n.macs[slot] = cmac.New(block)
+	return n.macs[slot], nil
+}
+
+// slot returns the index under which the key-dependent material of the passed round is kept,
+// a master key being the same at every round
+func (n *network) slot(index int) int {
+	switch n.schedule.(type) {
+	case MasterKey, SecretKey:
+		return 0
+	}
+	return index
+}
+
+//--- FUNCTIONS
+
+// newNetwork ...
+func newNetwork(function Round, expansion Expansion, mask Mask, engine hash.Engine, schedule KeySchedule) *network {
+	return &network{
+		function:  function,
+		expansion: expansion,
+		mask:      mask,
+		engine:    engine,
+		schedule:  schedule,
+		keys:      make(map[int][]byte),
+		macs:      make(map[int]*cmac.CMAC),
+	}
+}
+
+// add returns the legacy byte-wise sum of the item and the key extracted from the passed index, each sum being
+// UTF-8 encoded as a rune, without leaving any copy of the key in a string
+func add(item string, key []byte, index int) []byte {
+	added := make([]byte, 0, 2*len(item))
+	for i := 0; i < len(item); i++ {
+		added = utf8.AppendRune(added, rune(item[i]+key[(index+i)%len(key)]))
+	}
+	return added
+}
+
+// separate prefixes the item with the round index and its length for domain separation
+func separate(item string, index int) []byte {
+	buf := make([]byte, 8, 8+len(item))
+	binary.BigEndian.PutUint32(buf, uint32(index))
+	binary.BigEndian.PutUint32(buf[4:], uint32(len(item)))
+	return append(buf, item...)
+}
@@ -0,0 +1,242 @@
+package feistel_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cyrildever/feistel"
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/common/utils/runes"
+	utls "github.com/cyrildever/go-utls/common/utils"
+	"gotest.tools/assert"
+)
+
+// TestHMACRound ...
+func TestHMACRound(t *testing.T) {
+	ref := "Edgewhere"
+	key := "8ed9dcc1701c064f0fd7ae235f15143f989920e0ee9658bb7882c8d7d5f05692"
+
+	cipher := feistel.NewCipher(key, 10)
+	cipher.Round = feistel.HMAC_ROUND
+	found, err := cipher.Encrypt(ref)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "652f5d580c1202001e58")
+	deciphered, err := cipher.Decrypt(found)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, ref)
+
+	custom := feistel.NewCustomCipher([]string{
+		"1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
+		"9876543210fedcba9876543210fedcba9876543210fedcba9876543210fedcba",
+		"abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789",
+	})
+	custom.Round = feistel.HMAC_ROUND
+	found, err = custom.Encrypt(ref)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "4f5c574b530713683e6b")
+	deciphered, err = custom.Decrypt(found)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, ref)
+
+	vectors := map[hash.Engine]string{
+		hash.BLAKE2b: "2e0f585c4b0653120c",
+		hash.KECCAK:  "2f085a564d00051d09",
+		hash.SHA_256: "76575a02115f534851",
+		hash.SHA_3:   "75055c5d140102495a",
+	}
+	for engine, expected := range vectors {
+		fpe := feistel.NewFPECipher(engine, key, 10)
+		fpe.Round = feistel.HMAC_ROUND
+		obfuscated, err := fpe.Encrypt(ref)
+		assert.NilError(t, err)
+		assert.Equal(t, obfuscated.ToHex(), expected)
+		deciphered, err := fpe.Decrypt(obfuscated)
+		assert.NilError(t, err)
+		assert.Equal(t, deciphered, ref)
+	}
+
+	// Unknown round function
+	cipher.Round = "unknown"
+	_, err = cipher.Encrypt(ref)
+	assert.Error(t, err, "wrong cipher parameters: keys and rounds can't be null")
+}
+
+// TestAESRound ...
+func TestAESRound(t *testing.T) {
+	ref := "Edgewhere"
+	key := "8ed9dcc1701c064f0fd7ae235f15143f989920e0ee9658bb7882c8d7d5f05692"
+
+	cipher := feistel.NewCipher(key, 10)
+	cipher.Round = feistel.AES_ROUND
+	found, err := cipher.Encrypt(ref)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "6c770e0a0f1d5d0b440e")
+	deciphered, err := cipher.Decrypt(found)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, ref)
+
+	custom := feistel.NewCustomCipher([]string{
+		"1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
+		"9876543210fedcba9876543210fedcba9876543210fedcba9876543210fedcba",
+		"abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789",
+	})
+	custom.Round = feistel.AES_ROUND
+	found, err = custom.Encrypt(ref)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "445d5646000d17333b66")
+	deciphered, err = custom.Decrypt(found)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, ref)
+
+	fpe := feistel.NewFPECipher(hash.SHA_256, key, 10)
+	fpe.Round = feistel.AES_ROUND
+	obfuscated, err := fpe.Encrypt(ref)
+	assert.NilError(t, err)
+	assert.Equal(t, obfuscated.ToHex(), "7156550e165c5f130c")
+	deciphered, err = fpe.Decrypt(obfuscated)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, ref)
+
+	// The hash engine is irrelevant to the AES round function
+	fpe.Engine = hash.BLAKE2b
+	obfuscated, err = fpe.Encrypt(ref)
+	assert.NilError(t, err)
+	assert.Equal(t, obfuscated.ToHex(), "7156550e165c5f130c")
+
+	// The 16-byte output is expanded in counter mode, so long inputs show no period
+	long := strings.Repeat("a", 200)
+	found, err = cipher.Encrypt(long)
+	assert.NilError(t, err)
+	assert.Assert(t, !hasPeriod(utls.ToHex(found)))
+	obfuscated, err = fpe.Encrypt(long)
+	assert.NilError(t, err)
+	assert.Assert(t, !hasPeriod(obfuscated.ToHex()))
+	deciphered, err = fpe.Decrypt(obfuscated)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, long)
+}
+
+// TestCounterExpansion ...
+func TestCounterExpansion(t *testing.T) {
+	ref := "Edgewhere"
+	key := "8ed9dcc1701c064f0fd7ae235f15143f989920e0ee9658bb7882c8d7d5f05692"
+
+	cipher := feistel.NewCipher(key, 10)
+	cipher.Expansion = feistel.COUNTER_EXPANSION_V1
+	found, err := cipher.Encrypt(ref)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "66205702544d0e0d1f00")
+	deciphered, err := cipher.Decrypt(found)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, ref)
+
+	long := strings.Repeat(ref, 30)
+	found, err = cipher.Encrypt(long)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found)[:64], "7c58545011010c43562c57075010025d1d577e05045d48565946082650035213")
+	deciphered, err = cipher.Decrypt(found)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, long)
+
+	custom := feistel.NewCustomCipher([]string{
+		"1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
+		"9876543210fedcba9876543210fedcba9876543210fedcba9876543210fedcba",
+		"abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789",
+	})
+	custom.Expansion = feistel.COUNTER_EXPANSION_V1
+	found, err = custom.Encrypt(ref)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "445d5c17065815386635")
+
+	vectors := map[feistel.Round]string{
+		feistel.LEGACY_ROUND: "250d5a56185b02460c",
+		feistel.HMAC_ROUND:   "20055701120f074406",
+		feistel.AES_ROUND:    "7156550e165c5f130c",
+	}
+	for round, expected := range vectors {
+		fpe := feistel.NewFPECipher(hash.BLAKE2b, key, 10)
+		fpe.Round = round
+		fpe.Expansion = feistel.COUNTER_EXPANSION_V1
+		obfuscated, err := fpe.Encrypt(ref)
+		assert.NilError(t, err)
+		assert.Equal(t, obfuscated.ToHex(), expected)
+		deciphered, err := fpe.Decrypt(obfuscated)
+		assert.NilError(t, err)
+		assert.Equal(t, deciphered, ref)
+	}
+
+	// With two rounds and a zeroed left half, the left half of the result is the mask of the first round
+	src := strings.Repeat("\x00", 200) + strings.Repeat("a", 200)
+	cipher = feistel.NewCipher(key, 2)
+	found, err = cipher.Encrypt(src)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, found[:64], found[64:128]) // The legacy mask repeats every 64 bytes
+	cipher.Expansion = feistel.COUNTER_EXPANSION_V1
+	found, err = cipher.Encrypt(src)
+	assert.NilError(t, err)
+	assert.Assert(t, string(found[:64]) != string(found[64:128]))
+}
+
+// TestRawMask ...
+func TestRawMask(t *testing.T) {
+	ref := "Edgewhere"
+	key := "8ed9dcc1701c064f0fd7ae235f15143f989920e0ee9658bb7882c8d7d5f05692"
+
+	vectors := []struct {
+		round     feistel.Round
+		expansion feistel.Expansion
+		expected  string
+	}{
+		{feistel.LEGACY_ROUND, feistel.REPEAT_EXPANSION, "b2959f815c78d9f67bfe"}, // The raw mask is always expanded in counter mode
+		{feistel.LEGACY_ROUND, feistel.COUNTER_EXPANSION_V1, "b2959f815c78d9f67bfe"},
+		{feistel.HMAC_ROUND, feistel.COUNTER_EXPANSION_V1, "c50401816dcf095123fc"},
+	}
+	for _, vector := range vectors {
+		cipher := feistel.NewCipher(key, 10)
+		cipher.Round = vector.round
+		cipher.Expansion = vector.expansion
+		cipher.Mask = feistel.RAW_MASK_V1
+		found, err := cipher.Encrypt(ref)
+		assert.NilError(t, err)
+		assert.Equal(t, utls.ToHex(found), vector.expected)
+		deciphered, err := cipher.Decrypt(found)
+		assert.NilError(t, err)
+		assert.Equal(t, deciphered, ref)
+	}
+
+	custom := feistel.NewCustomCipher([]string{
+		"1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
+		"9876543210fedcba9876543210fedcba9876543210fedcba9876543210fedcba",
+		"abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789",
+	})
+	custom.Expansion = feistel.COUNTER_EXPANSION_V1
+	custom.Mask = feistel.RAW_MASK_V1
+	found, err := custom.Encrypt(ref)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "573c4c00fb76c6747b68")
+
+	fpe := feistel.NewFPECipher(hash.SHA_256, key, 10)
+	fpe.Expansion = feistel.COUNTER_EXPANSION_V1
+	fpe.Mask = feistel.RAW_MASK_V1
+	obfuscated, err := fpe.Encrypt(ref)
+	assert.NilError(t, err)
+	assert.Equal(t, obfuscated.ToHex(), "c4d91aaca8eae8fafd")
+	deciphered, err := fpe.Decrypt(obfuscated)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, ref)
+
+	fpe.Round = feistel.HMAC_ROUND
+	obfuscated, err = fpe.Encrypt(ref)
+	assert.NilError(t, err)
+	assert.Equal(t, obfuscated.ToHex(), "2e87ce5e2688afdb7c")
+
+	// The rune-level and small-domain modes read their numbers from raw bytes too
+	fpe.SmallDomain = true
+	for _, src := range []string{"a", "Amélie"} {
+		obfuscated, err := fpe.EncryptRunes(src, runes.LATIN_1)
+		assert.NilError(t, err)
+		deciphered, err := fpe.DecryptRunes(obfuscated, runes.LATIN_1)
+		assert.NilError(t, err)
+		assert.Equal(t, deciphered, src)
+	}
+}
@@ -0,0 +1,134 @@
+package feistel
+
+import (
+	"bytes"
+	"encoding/binary"
+	stdhash "hash"
+	"io"
+
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/exception"
+	"github.com/cyrildever/feistel/keys"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	HKDF_INFO     = "feistel/round-key" // Prefix of the HKDF info, followed by the round index as a 32-bit big-endian integer
+	HKDF_KEY_SIZE = 32                  // Default size in bytes of the HKDF-derived round keys
+)
+
+//--- TYPES
+
+// KeySchedule provides the key used by the round function at each round of the Feistel network
+type KeySchedule interface {
+	// RoundKey returns the key to use at the passed round index in a new slice, that the caller wipes after use
+	RoundKey(index int) ([]byte, error)
+}
+
+// MasterKey is the legacy key schedule using the same key at every round,
+// the legacy round function then extracting a different part of it from the round index
+type MasterKey string
+
+// SecretKey is the legacy MasterKey schedule backed by a destroyable secret
+type SecretKey struct {
+	Secret *keys.Secret
+}
+
+// RoundKeys uses an explicit key at each round, like the CustomCipher does
+type RoundKeys []string
+
+// SecretKeys is the RoundKeys schedule backed by destroyable secrets
+type SecretKeys []*keys.Secret
+
+// HKDFKeys derives independent round keys from the master key with HKDF (RFC 5869) using the hash engine,
+// ie. RoundKey(i) = HKDF(Key, Salt, HKDF_INFO || i) of `Size` bytes (HKDF_KEY_SIZE by default), the `Secret`
+// being used as master key instead of the `Key` if set
+type HKDFKeys struct {
+	Engine hash.Engine
+	Key    string
+	Secret *keys.Secret
+	Salt   []byte
+	Size   int
+}
+
+//--- METHODS
+
+// RoundKey ...
+func (mk MasterKey) RoundKey(index int) ([]byte, error) {
+	if len(mk) == 0 {
+		return nil, exception.NewWrongCipherParametersError()
+	}
+	return []byte(mk), nil
+}
+
+// RoundKey ...
+func (sk SecretKey) RoundKey(index int) ([]byte, error) {
+	if sk.Secret.Len() == 0 {
+		return nil, exception.NewWrongCipherParametersError()
+	}
+	return bytes.Clone(sk.Secret.Bytes()), nil
+}
+
+// RoundKey ...
+func (rk RoundKeys) RoundKey(index int) ([]byte, error) {
+	if index < 0 || index >= len(rk) || len(rk[index]) == 0 {
+		return nil, exception.NewWrongCipherParametersError()
+	}
+	return []byte(rk[index]), nil
+}
+
+// RoundKey ...
+func (sk SecretKeys) RoundKey(index int) ([]byte, error) {
+	if index < 0 || index >= len(sk) || sk[index].Len() == 0 {
+		return nil, exception.NewWrongCipherParametersError()
+	}
+	return bytes.Clone(sk[index].Bytes()), nil
+}
+
+// RoundKey ...
+func (hk HKDFKeys) RoundKey(index int) ([]byte, error) {
+	if !hk.isValid() || index < 0 {
+		return nil, exception.NewWrongCipherParametersError()
+	}
+	var master []byte
+	if hk.Secret != nil {
+		master = hk.Secret.Bytes()
+	} else {
+		master = []byte(hk.Key)
+		defer keys.Zeroize(master)
+	}
+	size := hk.Size
+	if size <= 0 {
+		size = HKDF_KEY_SIZE
+	}
+	info := make([]byte, len(HKDF_INFO)+4)
+	copy(info, HKDF_INFO)
+	binary.BigEndian.PutUint32(info[len(HKDF_INFO):], uint32(index))
+	factory := func() stdhash.Hash {
+		hasher, _ := hash.New(hk.Engine) // Already checked
+		return hasher
+	}
+	key := make([]byte, size)
+	if _, err := io.ReadFull(hkdf.New(factory, master, hk.Salt, info), key); err != nil {
+		keys.Zeroize(key)
+		return nil, err
+	}
+	return key, nil
+}
+
+func (hk HKDFKeys) isValid() bool {
+	if hk.Secret != nil {
+		return hk.Secret.Len() > 0 && hash.IsAvailableEngine(hk.Engine)
+	}
+	return len(hk.Key) > 0 && hash.IsAvailableEngine(hk.Engine)
+}
+
+//--- FUNCTIONS
+
+// NewHKDFKeys ...
+func NewHKDFKeys(engine hash.Engine, key string) *HKDFKeys {
+	return &HKDFKeys{
+		Engine: engine,
+		Key:    key,
+	}
+}
@@ -0,0 +1,176 @@
+package feistel_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cyrildever/feistel"
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/exception"
+	"github.com/cyrildever/feistel/keys"
+	utls "github.com/cyrildever/go-utls/common/utils"
+	"gotest.tools/assert"
+)
+
+// TestHKDFKeys ...
+func TestHKDFKeys(t *testing.T) {
+	ref := "Edgewhere"
+	key := "8ed9dcc1701c064f0fd7ae235f15143f989920e0ee9658bb7882c8d7d5f05692"
+	schedule := feistel.NewHKDFKeys(hash.SHA_256, key)
+
+	first, err := schedule.RoundKey(0)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(first), "2a8b8f7adb4706165b4532aa2083f2bd8e9a6bc2d9b10b6dde31f3a14a49a121")
+	second, err := schedule.RoundKey(1)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(second), "61354c8f0bbc332571ce0f46cbfacccc2a15c79fa8d174e014c317e276f38859")
+
+	cipher := feistel.NewCipher(key, 10)
+	cipher.Schedule = schedule
+	found, err := cipher.Encrypt(ref)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "6974060f05475f5a410f")
+	deciphered, err := cipher.Decrypt(found)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, ref)
+
+	cipher.Round = feistel.HMAC_ROUND
+	cipher.Expansion = feistel.COUNTER_EXPANSION_V1
+	cipher.Mask = feistel.RAW_MASK_V1
+	found, err = cipher.Encrypt(ref)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "ca99c7868ae87e21ea64")
+
+	fpe := feistel.NewFPECipher(hash.BLAKE2b, key, 10)
+	fpe.Schedule = feistel.NewHKDFKeys(hash.BLAKE2b, key)
+	obfuscated, err := fpe.Encrypt(ref)
+	assert.NilError(t, err)
+	assert.Equal(t, obfuscated.ToHex(), "71540e5e400c0f1607")
+	decrypted, err := fpe.Decrypt(obfuscated)
+	assert.NilError(t, err)
+	assert.Equal(t, decrypted, ref)
+
+	_, err = feistel.NewHKDFKeys("md5", key).RoundKey(0)
+	_, ok := err.(*exception.WrongCipherParametersError)
+	assert.Assert(t, ok)
+}
+
+// TestRoundKeys ...
+func TestRoundKeys(t *testing.T) {
+	ref := "Edgewhere"
+	keys := []string{
+		"1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
+		"9876543210fedcba9876543210fedcba9876543210fedcba9876543210fedcba",
+		"abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789",
+	}
+
+	// Explicit round keys are the CustomCipher's ones
+	cipher := &feistel.Cipher{Rounds: len(keys), Schedule: feistel.RoundKeys(keys)}
+	found, err := cipher.Encrypt(ref)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "445951465c5a19613633")
+
+	// The legacy schedule is the master key
+	cipher = &feistel.Cipher{Rounds: 10, Schedule: feistel.MasterKey("8ed9dcc1701c064f0fd7ae235f15143f989920e0ee9658bb7882c8d7d5f05692")}
+	found, err = cipher.Encrypt(ref)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "3d7c0a0f51415a521054")
+
+	// Not enough round keys
+	cipher = &feistel.Cipher{Rounds: 10, Schedule: feistel.RoundKeys(keys)}
+	_, err = cipher.Encrypt(ref)
+	_, ok := err.(*exception.WrongCipherParametersError)
+	assert.Assert(t, ok)
+}
+
+// TestSecretKeys ...
+func TestSecretKeys(t *testing.T) {
+	cipher := feistel.NewSecretCipher(hash.SHA_256, keys.NewSecret([]byte(katKey)), 10)
+	obfuscated, err := cipher.Encrypt(katSource)
+	assert.NilError(t, err)
+	expected, _ := feistel.NewCipher(katKey, 10).Encrypt(katSource)
+	assert.DeepEqual(t, obfuscated, expected)
+	assert.Equal(t, cipher.Version(), feistel.V1_LEGACY)
+
+	secrets := make([]*keys.Secret, len(katKeys))
+	for i, key := range katKeys {
+		secrets[i] = keys.NewSecret([]byte(key))
+	}
+	custom := feistel.NewSecretCustomCipher(hash.SHA_256, secrets)
+	obfuscated, err = custom.Encrypt(katSource)
+	assert.NilError(t, err)
+	expected, _ = feistel.NewCustomCipher(katKeys).Encrypt(katSource)
+	assert.DeepEqual(t, obfuscated, expected)
+	assert.Equal(t, custom.Version(), feistel.V1_LEGACY)
+
+	secret := keys.NewSecret([]byte(katShortKey))
+	fpe := feistel.NewSecretFPECipher(hash.SHA_256, secret, 128)
+	readable, err := fpe.EncryptString("my-source-data")
+	assert.NilError(t, err)
+	legacy, _ := feistel.NewFPECipher(hash.SHA_256, katShortKey, 128).EncryptString("my-source-data")
+	assert.Equal(t, readable, legacy)
+
+	// HKDF-derived round keys
+	v2, _ := feistel.NewVersionedFPECipher(feistel.V2, hash.SHA_256, katShortKey, 10)
+	fpe.Rounds = 10
+	fpe.Round, fpe.Expansion, fpe.Mask, fpe.SmallDomain = v2.Round, v2.Expansion, v2.Mask, v2.SmallDomain
+	fpe.Schedule = &feistel.HKDFKeys{Engine: hash.SHA_256, Secret: secret}
+	assert.Equal(t, fpe.Version(), feistel.V2)
+	readable, err = fpe.EncryptString("my-source-data")
+	assert.NilError(t, err)
+	expectedReadable, _ := v2.EncryptString("my-source-data")
+	assert.Equal(t, readable, expectedReadable)
+
+	// Keys are never printed
+	assert.Assert(t, !strings.Contains(fmt.Sprintf("%+v %#v", *fpe, *custom), "key"))
+
+	secret.Destroy()
+	_, err = fpe.EncryptString("my-source-data")
+	assert.Error(t, err, exception.NewWrongCipherParametersError().Error())
+	secrets[1].Destroy()
+	_, err = custom.Encrypt(katSource)
+	assert.Error(t, err, exception.NewWrongCipherParametersError().Error())
+}
+
+// TestRoundKeysWiped ...
+func TestRoundKeysWiped(t *testing.T) {
+	secret := keys.NewSecret([]byte(katKey))
+	secrets := []*keys.Secret{keys.NewSecret([]byte(katKeys[0])), keys.NewSecret([]byte(katKeys[1]))}
+	for _, schedule := range []feistel.KeySchedule{
+		feistel.SecretKey{Secret: secret},
+		feistel.SecretKeys(secrets),
+		&feistel.HKDFKeys{Engine: hash.SHA_256, Secret: secret},
+	} {
+		for _, round := range []feistel.Round{feistel.LEGACY_ROUND, feistel.HMAC_ROUND, feistel.AES_ROUND} {
+			var given [][]byte
+			cipher := feistel.NewCipher(katKey, 2)
+			cipher.Round = round
+			cipher.Schedule = recordingKeys{schedule, &given}
+			obfuscated, err := cipher.Encrypt(katSource)
+			assert.NilError(t, err)
+			deciphered, err := cipher.Decrypt(obfuscated)
+			assert.NilError(t, err)
+			assert.Equal(t, deciphered, katSource)
+
+			assert.Assert(t, len(given) > 0)
+			for _, key := range given {
+				assert.DeepEqual(t, key, make([]byte, len(key)))
+			}
+		}
+	}
+	assert.Equal(t, string(secret.Bytes()), katKey) // The secrets themselves are left untouched
+	assert.Equal(t, string(secrets[1].Bytes()), katKeys[1])
+}
+
+// recordingKeys is a key schedule keeping track of the round keys it hands out
+type recordingKeys struct {
+	feistel.KeySchedule
+	given *[][]byte
+}
+
+func (rk recordingKeys) RoundKey(index int) ([]byte, error) {
+	key, err := rk.KeySchedule.RoundKey(index)
+	*rk.given = append(*rk.given, key)
+	return key, err
+}
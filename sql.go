@@ -0,0 +1,272 @@
+package feistel
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/cyrildever/feistel/common/utils/base256"
+	"github.com/cyrildever/feistel/exception"
+)
+
+const DEFAULT_COLUMN = ""
+
+var columns = struct {
+	sync.RWMutex
+	registered map[string]Column
+}{
+	registered: make(map[string]Column),
+}
+
+//--- TYPES
+
+// Storage defines how obfuscated values are stored in the database
+type Storage string
+
+const (
+	BYTES_STORAGE    Storage = ""         // The raw bytes, eg. in a BLOB or a BYTEA column (default)
+	HEX_STORAGE      Storage = "hex"      // The hexadecimal string of the raw bytes
+	READABLE_STORAGE Storage = "readable" // The base-256 readable string of the raw bytes
+)
+
+// Column binds a cipher and a storage format to database columns
+type Column struct {
+	Cipher  Obfuscator
+	Storage Storage
+}
+
+type columnKey struct{}
+
+// EncryptedString is a nullable string column, like sql.NullString, that is transparently encrypted when written to
+// the database and deciphered when read.
+// Its cipher is the column bound by Bind() if any, or else the registered column of the `Column` name (the default one if empty).
+type EncryptedString struct {
+	String string
+	Valid  bool
+	Column string
+
+	bound *Column
+}
+
+// EncryptedUint64 is a nullable unsigned integer column that is transparently encrypted when written to the database
+// and deciphered when read, the FPECipher using its EncryptNumber() method.
+// Its cipher is the column bound by Bind() if any, or else the registered column of the `Column` name (the default one if empty).
+type EncryptedUint64 struct {
+	Uint64 uint64
+	Valid  bool
+	Column string
+
+	bound *Column
+}
+
+//--- METHODS
+
+// IsValid ...
+func (s Storage) IsValid() bool {
+	return s == BYTES_STORAGE || s == HEX_STORAGE || s == READABLE_STORAGE
+}
+
+// Bind uses the column of the passed context, if any
+func (es *EncryptedString) Bind(ctx context.Context) *EncryptedString {
+	if column, ok := ColumnFrom(ctx); ok {
+		es.bound = &column
+	}
+	return es
+}
+
+// Scan implements the sql.Scanner interface
+func (es *EncryptedString) Scan(src any) error {
+	if src == nil {
+		es.String, es.Valid = "", false
+		return nil
+	}
+	column, err := lookupColumn(es.bound, es.Column)
+	if err != nil {
+		return err
+	}
+	raw, err := column.decode(src)
+	if err != nil {
+		return err
+	}
+	deciphered, err := column.Cipher.Deobfuscate(raw)
+	if err != nil {
+		return err
+	}
+	es.String, es.Valid = deciphered, true
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (es EncryptedString) Value() (driver.Value, error) {
+	if !es.Valid {
+		return nil, nil
+	}
+	column, err := lookupColumn(es.bound, es.Column)
+	if err != nil {
+		return nil, err
+	}
+	ciphered, err := column.Cipher.Obfuscate(es.String)
+	if err != nil {
+		return nil, err
+	}
+	return column.encode(ciphered), nil
+}
+
+// Bind uses the column of the passed context, if any
+func (eu *EncryptedUint64) Bind(ctx context.Context) *EncryptedUint64 {
+	if column, ok := ColumnFrom(ctx); ok {
+		eu.bound = &column
+	}
+	return eu
+}
+
+// Scan implements the sql.Scanner interface
+func (eu *EncryptedUint64) Scan(src any) error {
+	if src == nil {
+		eu.Uint64, eu.Valid = 0, false
+		return nil
+	}
+	column, err := lookupColumn(eu.bound, eu.Column)
+	if err != nil {
+		return err
+	}
+	raw, err := column.decode(src)
+	if err != nil {
+		return err
+	}
+	var number uint64
+	if fpe, ok := asFPECipher(column.Cipher); ok {
+		number, err = fpe.DecryptNumber(base256.ToBase256Readable(raw))
+	} else {
+		var deciphered string
+		if deciphered, err = column.Cipher.Deobfuscate(raw); err == nil {
+			number, err = strconv.ParseUint(deciphered, 10, 64)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	eu.Uint64, eu.Valid = number, true
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (eu EncryptedUint64) Value() (driver.Value, error) {
+	if !eu.Valid {
+		return nil, nil
+	}
+	column, err := lookupColumn(eu.bound, eu.Column)
+	if err != nil {
+		return nil, err
+	}
+	var ciphered []byte
+	if fpe, ok := asFPECipher(column.Cipher); ok {
+		readable, e := fpe.EncryptNumber(eu.Uint64)
+		var tooSmall *exception.TooSmallToPreserveLengthError
+		if e != nil && !errors.As(e, &tooSmall) { // Small numbers are still properly encrypted
+			return nil, e
+		}
+		ciphered = readable.Bytes()
+	} else if ciphered, err = column.Cipher.Obfuscate(strconv.FormatUint(eu.Uint64, 10)); err != nil {
+		return nil, err
+	}
+	return column.encode(ciphered), nil
+}
+
+func (c Column) encode(ciphered []byte) driver.Value {
+	switch c.Storage {
+	case HEX_STORAGE:
+		return hex.EncodeToString(ciphered)
+	case READABLE_STORAGE:
+		return base256.ToBase256Readable(ciphered).String()
+	default:
+		return ciphered
+	}
+}
+
+func (c Column) decode(src any) ([]byte, error) {
+	var stored string
+	switch value := src.(type) {
+	case []byte:
+		stored = string(value)
+	case string:
+		stored = value
+	default:
+		return nil, exception.NewInvalidEncodingError()
+	}
+	switch c.Storage {
+	case HEX_STORAGE:
+		raw, err := hex.DecodeString(stored)
+		if err != nil {
+			return nil, exception.NewInvalidEncodingError()
+		}
+		return raw, nil
+	case READABLE_STORAGE:
+		readable, err := base256.Decode(stored, base256.READABLE_ENCODING)
+		if err != nil {
+			return nil, err
+		}
+		return readable.Bytes(), nil
+	default:
+		return []byte(stored), nil
+	}
+}
+
+//--- FUNCTIONS
+
+// RegisterColumn makes a column available under the passed name, DEFAULT_COLUMN being used by the encrypted types
+// whose `Column` name is empty.
+// It panics if the cipher is nil, the storage invalid or if the name is already registered.
+func RegisterColumn(name string, column Column) {
+	if column.Cipher == nil || !column.Storage.IsValid() {
+		panic(exception.NewWrongCipherParametersError())
+	}
+	columns.Lock()
+	defer columns.Unlock()
+	if _, exists := columns.registered[name]; exists {
+		panic(exception.NewDuplicateColumnError())
+	}
+	columns.registered[name] = column
+}
+
+// WithColumn returns a copy of the passed context holding the column to bind the encrypted types to
+func WithColumn(ctx context.Context, column Column) context.Context {
+	return context.WithValue(ctx, columnKey{}, column)
+}
+
+// ColumnFrom returns the column held by the passed context, if any
+func ColumnFrom(ctx context.Context) (Column, bool) {
+	column, ok := ctx.Value(columnKey{}).(Column)
+	return column, ok
+}
+
+//--- utilities
+
+func lookupColumn(bound *Column, name string) (*Column, error) {
+	if bound != nil {
+		if bound.Cipher == nil || !bound.Storage.IsValid() {
+			return nil, exception.NewWrongCipherParametersError()
+		}
+		return bound, nil
+	}
+	columns.RLock()
+	defer columns.RUnlock()
+	column, ok := columns.registered[name]
+	if !ok {
+		return nil, exception.NewUnknownColumnError()
+	}
+	return &column, nil
+}
+
+func asFPECipher(cipher Obfuscator) (*FPECipher, bool) {
+	switch c := cipher.(type) {
+	case *FPECipher:
+		return c, true
+	case FPECipher:
+		return &c, true
+	}
+	return nil, false
+}
@@ -0,0 +1,102 @@
+package feistel_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cyrildever/feistel"
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/exception"
+	utls "github.com/cyrildever/go-utls/common/utils"
+	"gotest.tools/assert"
+)
+
+func init() {
+	feistel.RegisterColumn(feistel.DEFAULT_COLUMN, feistel.Column{
+		Cipher: feistel.NewCipher(katKey, 10),
+	})
+	feistel.RegisterColumn("fpe-hex", feistel.Column{
+		Cipher:  feistel.NewFPECipher(hash.SHA_256, katKey, 10),
+		Storage: feistel.HEX_STORAGE,
+	})
+}
+
+// TestEncryptedString ...
+func TestEncryptedString(t *testing.T) {
+	email := feistel.EncryptedString{String: katSource, Valid: true}
+	value, err := email.Value()
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(value.([]byte)), "3d7c0a0f51415a521054")
+	var found feistel.EncryptedString
+	assert.NilError(t, found.Scan(value))
+	assert.Equal(t, found.String, katSource)
+	assert.Assert(t, found.Valid)
+
+	// Named column
+	email = feistel.EncryptedString{String: katSource, Valid: true, Column: "fpe-hex"}
+	value, err = email.Value()
+	assert.NilError(t, err)
+	assert.Equal(t, value, "2a5d07024f5a501409")
+	found = feistel.EncryptedString{Column: "fpe-hex"}
+	assert.NilError(t, found.Scan([]byte("2a5d07024f5a501409")))
+	assert.Equal(t, found.String, katSource)
+
+	// Bound via context
+	ctx := feistel.WithColumn(context.Background(), feistel.Column{
+		Cipher:  feistel.NewFPECipher(hash.SHA_256, katKey, 10),
+		Storage: feistel.READABLE_STORAGE,
+	})
+	email = feistel.EncryptedString{String: katSource, Valid: true}
+	value, err = email.Bind(ctx).Value()
+	assert.NilError(t, err)
+	assert.Equal(t, value, "K¡(#q|r5*")
+	found = feistel.EncryptedString{}
+	assert.NilError(t, found.Bind(ctx).Scan("K¡(#q|r5*"))
+	assert.Equal(t, found.String, katSource)
+
+	// NULL
+	value, err = feistel.EncryptedString{}.Value()
+	assert.NilError(t, err)
+	assert.Assert(t, value == nil)
+	assert.NilError(t, found.Scan(nil))
+	assert.Assert(t, !found.Valid)
+
+	// Errors
+	_, err = feistel.EncryptedString{String: katSource, Valid: true, Column: "unknown"}.Value()
+	assert.Error(t, err, exception.NewUnknownColumnError().Error())
+	found = feistel.EncryptedString{Column: "fpe-hex"}
+	err = found.Scan("not hexadecimal")
+	assert.Error(t, err, exception.NewInvalidEncodingError().Error())
+	err = found.Scan(42)
+	assert.Error(t, err, exception.NewInvalidEncodingError().Error())
+}
+
+// TestEncryptedUint64 ...
+func TestEncryptedUint64(t *testing.T) {
+	for _, number := range []uint64{0, 42, 123456789} {
+		for _, name := range []string{feistel.DEFAULT_COLUMN, "fpe-hex"} {
+			value, err := feistel.EncryptedUint64{Uint64: number, Valid: true, Column: name}.Value()
+			assert.NilError(t, err)
+			found := feistel.EncryptedUint64{Column: name}
+			assert.NilError(t, found.Scan(value))
+			assert.Equal(t, found.Uint64, number)
+			assert.Assert(t, found.Valid)
+		}
+	}
+	value, err := feistel.EncryptedUint64{Uint64: 123456789, Valid: true, Column: "fpe-hex"}.Value()
+	assert.NilError(t, err)
+	readable, _ := feistel.NewFPECipher(hash.SHA_256, katKey, 10).EncryptNumber(123456789)
+	assert.Equal(t, value, readable.ToHex())
+
+	value, err = feistel.EncryptedUint64{}.Value()
+	assert.NilError(t, err)
+	assert.Assert(t, value == nil)
+}
+
+// TestRegisterColumn ...
+func TestRegisterColumn(t *testing.T) {
+	defer func() {
+		assert.Error(t, recover().(error), exception.NewDuplicateColumnError().Error())
+	}()
+	feistel.RegisterColumn("fpe-hex", feistel.Column{Cipher: feistel.NewCipher(katKey, 10)})
+}
@@ -0,0 +1,461 @@
+package feistel
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/cyrildever/feistel/common/utils/base256"
+	"github.com/cyrildever/feistel/common/utils/runes"
+	"github.com/cyrildever/feistel/exception"
+)
+
+const (
+	DEFAULT_FIELD_KEY = "default" // Name of the cipher used by the tags without a `key=` option
+	FIELD_TAG         = "feistel" // Name of the struct tag
+
+	EMAIL_ALPHABET = "0123456789abcdefghijklmnopqrstuvwxyz" // Characters of an e-mail address that the `email` mode encrypts
+)
+
+// Modes of the struct tags
+const (
+	EMAIL_FIELD  = "email"
+	FPE_FIELD    = "fpe"
+	NUMBER_FIELD = "number"
+)
+
+//--- TYPES
+
+// CipherRegistry provides the ciphers referenced by name in the struct tags, eg. a Keyring or Ciphers
+type CipherRegistry interface {
+	Cipher(name string) (Obfuscator, error)
+}
+
+// Ciphers is a CipherRegistry holding ciphers by name
+type Ciphers map[string]Obfuscator
+
+type fieldTag struct {
+	mode       string
+	key        string
+	digits     bool
+	keepDomain bool
+}
+
+type structWalker struct {
+	registry CipherRegistry
+	reverse  bool
+	visited  map[visit]bool
+}
+
+type visit struct {
+	pointer uintptr
+	typ     reflect.Type
+}
+
+//--- METHODS
+
+// Cipher ...
+func (c Ciphers) Cipher(name string) (Obfuscator, error) {
+	cipher, ok := c[name]
+	if !ok || cipher == nil {
+		return nil, exception.NewUnknownKeyIDError()
+	}
+	return cipher, nil
+}
+
+// walk processes the tagged fields of the passed value and of all its nested structs, pointers, slices, arrays and maps
+func (w *structWalker) walk(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || w.seen(v) {
+			return nil
+		}
+		return w.walk(v.Elem())
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return w.replace(v, v.Elem(), w.walk)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() && !(field.Anonymous && field.Type.Kind() == reflect.Struct) {
+				continue
+			}
+			tag, ok := field.Tag.Lookup(FIELD_TAG)
+			if !ok {
+				if err := w.walk(v.Field(i)); err != nil {
+					return err
+				}
+				continue
+			}
+			if tag == "-" {
+				continue
+			}
+			parsed, err := parseFieldTag(tag)
+			if err != nil {
+				return err
+			}
+			if err := w.apply(v.Field(i), parsed); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := w.walk(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			if err := w.replaceMapValue(v, key, w.walk); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// apply processes the passed tagged field, each of its elements if it's a collection
+func (w *structWalker) apply(v reflect.Value, tag fieldTag) error {
+	process := func(elem reflect.Value) error {
+		return w.apply(elem, tag)
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || w.seen(v) {
+			return nil
+		}
+		return w.apply(v.Elem(), tag)
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return w.replace(v, v.Elem(), process)
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return exception.NewInvalidTagError()
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := w.apply(v.Index(i), tag); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			if err := w.replaceMapValue(v, key, process); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return w.field(v, tag)
+}
+
+// field encrypts or deciphers the passed value in place with the cipher of the tag
+func (w *structWalker) field(v reflect.Value, tag fieldTag) error {
+	isString := v.Kind() == reflect.String
+	isInteger := isSigned(v.Kind()) || isUnsigned(v.Kind())
+	if !v.CanSet() || (tag.mode == NUMBER_FIELD && !isInteger && !(isString && tag.digits)) || (tag.mode != NUMBER_FIELD && !isString) {
+		return exception.NewInvalidTagError()
+	}
+	if isString && v.Len() == 0 {
+		return nil
+	}
+	cipher, err := w.registry.Cipher(tag.key)
+	if err != nil {
+		return err
+	}
+	f, ok := asFPECipher(cipher)
+	if !ok || !f.isValid() {
+		return exception.NewWrongCipherParametersError()
+	}
+	switch {
+	case tag.mode == FPE_FIELD:
+		var str string
+		if w.reverse {
+			str, err = f.DecryptString(base256.Readable(v.String()))
+		} else {
+			var ciphered base256.Readable
+			ciphered, err = f.EncryptString(v.String())
+			str = ciphered.String()
+		}
+		if err != nil {
+			return err
+		}
+		v.SetString(str)
+	case tag.mode == EMAIL_FIELD:
+		str, err := f.permuteEmail(v.String(), tag.keepDomain, w.reverse)
+		if err != nil {
+			return err
+		}
+		v.SetString(str)
+	case isString:
+		digits, err := toDigits(v.String(), runes.DIGITS)
+		if err != nil {
+			return err
+		}
+		digits, err = f.permuteDecimal(digits, nil, w.reverse)
+		if err != nil {
+			return err
+		}
+		v.SetString(fromDigits(digits, runes.DIGITS))
+	case tag.digits:
+		return f.permuteInteger(v, w.reverse)
+	default:
+		return f.permuteWord(v, w.reverse)
+	}
+	return nil
+}
+
+// replace processes a copy of the passed element as it isn't addressable, eg. the value of an interface, then sets it back
+func (w *structWalker) replace(v, elem reflect.Value, process func(reflect.Value) error) error {
+	copied := reflect.New(elem.Type()).Elem()
+	copied.Set(elem)
+	if err := process(copied); err != nil {
+		return err
+	}
+	v.Set(copied)
+	return nil
+}
+
+func (w *structWalker) replaceMapValue(v, key reflect.Value, process func(reflect.Value) error) error {
+	copied := reflect.New(v.Type().Elem()).Elem()
+	copied.Set(v.MapIndex(key))
+	if err := process(copied); err != nil {
+		return err
+	}
+	v.SetMapIndex(key, copied)
+	return nil
+}
+
+// seen tells whether the passed pointer was already processed, so that shared values are only processed once
+// and cyclic structures don't loop
+func (w *structWalker) seen(v reflect.Value) bool {
+	key := visit{v.Pointer(), v.Type()}
+	if w.visited[key] {
+		return true
+	}
+	w.visited[key] = true
+	return false
+}
+
+// permuteDecimal applies the FPE cipher to the passed decimal digits, walking the cycle until the result is valid if a
+// validation function is passed
+func (f FPECipher) permuteDecimal(digits []int, isValid func([]int) bool, reverse bool) ([]int, error) {
+	for {
+		var err error
+		if reverse {
+			digits, err = f.decryptDigits(digits, 10)
+		} else {
+			digits, err = f.encryptDigits(digits, 10)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if isValid == nil || isValid(digits) {
+			return digits, nil
+		}
+	}
+}
+
+// permuteEmail applies the FPE cipher to the alphanumeric characters of the passed e-mail address, lowercased,
+// the other characters being kept in place
+func (f FPECipher) permuteEmail(email string, keepDomain, reverse bool) (string, error) {
+	local, domain := email, ""
+	if at := strings.LastIndexByte(email, '@'); keepDomain && at != -1 {
+		local, domain = email[:at], email[at:]
+	}
+	chars := []rune(strings.ToLower(local))
+	var digits, positions []int
+	for i, char := range chars {
+		if index := strings.IndexRune(EMAIL_ALPHABET, char); index != -1 {
+			digits = append(digits, index)
+			positions = append(positions, i)
+		}
+	}
+	if len(digits) == 0 {
+		return email, nil
+	}
+	var err error
+	if reverse {
+		digits, err = f.decryptDigits(digits, len(EMAIL_ALPHABET))
+	} else {
+		digits, err = f.encryptDigits(digits, len(EMAIL_ALPHABET))
+	}
+	if err != nil {
+		return "", err
+	}
+	for i, position := range positions {
+		chars[position] = rune(EMAIL_ALPHABET[digits[i]])
+	}
+	return string(chars) + domain, nil
+}
+
+// permuteInteger applies the FPE cipher to the decimal digits of the passed integer, keeping its sign and its number
+// of digits, and walking the cycle until the result has no leading zero and fits in the integer type
+func (f FPECipher) permuteInteger(v reflect.Value, reverse bool) error {
+	size := v.Type().Bits()
+	var magnitude, max uint64
+	negative := false
+	if isSigned(v.Kind()) {
+		max = uint64(1)<<(size-1) - 1
+		n := v.Int()
+		if n < 0 {
+			if n == math.MinInt64>>(64-size) {
+				return exception.NewOutOfRangeError()
+			}
+			negative, n = true, -n
+		}
+		magnitude = uint64(n)
+	} else {
+		max = math.MaxUint64 >> (64 - size)
+		magnitude = v.Uint()
+	}
+	digits, _ := toDigits(strconv.FormatUint(magnitude, 10), runes.DIGITS) // Always valid
+	digits, err := f.permuteDecimal(digits, func(digits []int) bool {
+		if len(digits) > 1 && digits[0] == 0 {
+			return false
+		}
+		n, err := strconv.ParseUint(fromDigits(digits, runes.DIGITS), 10, 64)
+		return err == nil && n <= max
+	}, reverse)
+	if err != nil {
+		return err
+	}
+	magnitude, _ = strconv.ParseUint(fromDigits(digits, runes.DIGITS), 10, 64) // Already checked
+	if !isSigned(v.Kind()) {
+		v.SetUint(magnitude)
+	} else if negative {
+		v.SetInt(-int64(magnitude))
+	} else {
+		v.SetInt(int64(magnitude))
+	}
+	return nil
+}
+
+// permuteWord applies the FPE cipher to the big-endian bytes of the passed integer, ie. over the whole domain of its type
+func (f FPECipher) permuteWord(v reflect.Value, reverse bool) error {
+	size := v.Type().Bits() / 8
+	var word uint64
+	if isSigned(v.Kind()) {
+		word = uint64(v.Int())
+	} else {
+		word = v.Uint()
+	}
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, word)
+	buf = buf[8-size:]
+
+	// Numbers are binary data that mustn't be normalized
+	f.Normalization = runes.NONE
+	var permuted []byte
+	if reverse {
+		deciphered, err := f.Decrypt(base256.ToBase256Readable(buf))
+		if err != nil {
+			return err
+		}
+		permuted = []byte(deciphered)
+	} else {
+		ciphered, err := f.Encrypt(string(buf))
+		if err != nil {
+			return err
+		}
+		permuted = ciphered.Bytes()
+	}
+	if len(permuted) != size {
+		return exception.NewNotUint64Error()
+	}
+	var result uint64
+	for _, b := range permuted {
+		result = result<<8 | uint64(b)
+	}
+	if isSigned(v.Kind()) {
+		shift := 64 - 8*size
+		v.SetInt(int64(result<<shift) >> shift)
+	} else {
+		v.SetUint(result)
+	}
+	return nil
+}
+
+//--- FUNCTIONS
+
+// EncryptStruct encrypts in place the fields of the passed target (a non-nil pointer, usually to a struct) according
+// to their `feistel` struct tag, made of a mode followed by comma-separated options:
+//   - `fpe`: the string is replaced by the base-256 readable string of its FPECipher encryption;
+//   - `email`: the alphanumeric characters of the e-mail address are lowercased and replaced by other ones (see
+//     EMAIL_ALPHABET), the `keepDomain` option restricting the encryption to the part before the last `@`;
+//   - `number`: the integer is replaced by another integer of the same type, or with the `digits` option, the integer
+//     (or the string of decimal digits) is replaced by another one with the same number of digits (and sign).
+//
+// The `key=NAME` option selects the cipher of the registry to use (DEFAULT_FIELD_KEY by default), which must be
+// an FPECipher. A tag applied to a pointer, a slice, an array or a map applies to each of its elements (map keys
+// are left as is), whereas the untagged fields are searched for tagged ones in nested structures.
+// The unexported fields and those tagged `feistel:"-"` are ignored.
+//
+// NB: Single-character (or single-digit) values are only encrypted by a cipher in small-domain mode.
+// In case of error, the target may be partially encrypted.
+func EncryptStruct(target any, registry CipherRegistry) error {
+	return processStruct(target, registry, false)
+}
+
+// DecryptStruct reverses the EncryptStruct() function using the same registry,
+// the e-mail addresses being deciphered in lowercase
+func DecryptStruct(target any, registry CipherRegistry) error {
+	return processStruct(target, registry, true)
+}
+
+//--- utilities
+
+func processStruct(target any, registry CipherRegistry, reverse bool) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return exception.NewInvalidTargetError()
+	}
+	if registry == nil {
+		return exception.NewWrongCipherParametersError()
+	}
+	walker := &structWalker{
+		registry: registry,
+		reverse:  reverse,
+		visited:  make(map[visit]bool),
+	}
+	return walker.walk(v)
+}
+
+func parseFieldTag(tag string) (parsed fieldTag, err error) {
+	options := strings.Split(tag, ",")
+	parsed.mode = strings.TrimSpace(options[0])
+	parsed.key = DEFAULT_FIELD_KEY
+	if parsed.mode != EMAIL_FIELD && parsed.mode != FPE_FIELD && parsed.mode != NUMBER_FIELD {
+		err = exception.NewInvalidTagError()
+		return
+	}
+	for _, option := range options[1:] {
+		option = strings.TrimSpace(option)
+		switch {
+		case strings.HasPrefix(option, "key=") && len(option) > len("key="):
+			parsed.key = option[len("key="):]
+		case option == "digits" && parsed.mode == NUMBER_FIELD:
+			parsed.digits = true
+		case option == "keepDomain" && parsed.mode == EMAIL_FIELD:
+			parsed.keepDomain = true
+		default:
+			err = exception.NewInvalidTagError()
+			return
+		}
+	}
+	return
+}
+
+func isSigned(kind reflect.Kind) bool {
+	return kind == reflect.Int || kind == reflect.Int8 || kind == reflect.Int16 || kind == reflect.Int32 || kind == reflect.Int64
+}
+
+func isUnsigned(kind reflect.Kind) bool {
+	return kind == reflect.Uint || kind == reflect.Uint8 || kind == reflect.Uint16 || kind == reflect.Uint32 || kind == reflect.Uint64 || kind == reflect.Uintptr
+}
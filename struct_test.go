@@ -0,0 +1,190 @@
+package feistel_test
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/cyrildever/feistel"
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/exception"
+	"gotest.tools/assert"
+)
+
+type address struct {
+	Street string `feistel:"fpe"`
+	City   string
+}
+
+type audit struct {
+	By string `feistel:"fpe"`
+}
+
+type user struct {
+	audit
+	Name     string            `feistel:"fpe,key=pii"`
+	Email    string            `feistel:"email,keepDomain"`
+	Backup   string            `feistel:"email"`
+	Phone    string            `feistel:"number,digits"`
+	Account  uint64            `feistel:"number,digits"`
+	Score    int32             `feistel:"number,digits"`
+	Balance  int64             `feistel:"number"`
+	Aliases  []string          `feistel:"fpe,key=pii"`
+	Codes    map[string]uint16 `feistel:"number"`
+	Home     *address
+	Previous []address
+	Offices  map[string]*address
+	Extra    any
+	Notes    string
+	Ignored  string `feistel:"-"`
+	internal string
+}
+
+func newUser() *user {
+	return &user{
+		audit:    audit{By: "admin"},
+		Name:     katSource,
+		Email:    "John.Doe+news@example.com",
+		Backup:   "jdoe@edgewhere.fr",
+		Phone:    "0612345678",
+		Account:  1234567890123456,
+		Score:    -4242,
+		Balance:  -1,
+		Aliases:  []string{"JD", "Johnny"},
+		Codes:    map[string]uint16{"a": 1, "b": 65535},
+		Home:     &address{Street: "1 rue de la Paix", City: "Paris"},
+		Previous: []address{{Street: "Baker Street", City: "London"}},
+		Offices:  map[string]*address{"hq": {Street: "Main Street", City: "Boston"}},
+		Extra:    &address{Street: "Broadway", City: "New York"},
+		Notes:    "untouched",
+		Ignored:  "untouched",
+		internal: "untouched",
+	}
+}
+
+func newRegistry() feistel.Ciphers {
+	return feistel.Ciphers{
+		feistel.DEFAULT_FIELD_KEY: feistel.NewFPECipher(hash.SHA_256, katKey, 10),
+		"pii":                     feistel.NewFPECipher(hash.BLAKE2b, katShortKey, 10),
+	}
+}
+
+// TestEncryptStruct ...
+func TestEncryptStruct(t *testing.T) {
+	registry := newRegistry()
+	u := newUser()
+	err := feistel.EncryptStruct(u, registry)
+	assert.NilError(t, err)
+
+	expected, _ := registry["pii"].(*feistel.FPECipher).EncryptString(katSource)
+	assert.Equal(t, u.Name, expected.String())
+	assert.Assert(t, u.Aliases[0] != "JD" && utf8.RuneCountInString(u.Aliases[1]) == len("Johnny"))
+
+	assert.Assert(t, u.Email != "john.doe+news@example.com")
+	assert.Assert(t, regexp.MustCompile(`^[0-9a-z]{4}\.[0-9a-z]{3}\+[0-9a-z]{4}@example\.com$`).MatchString(u.Email), u.Email)
+	assert.Assert(t, regexp.MustCompile(`^[0-9a-z]{4}@[0-9a-z]{9}\.[0-9a-z]{2}$`).MatchString(u.Backup), u.Backup)
+	assert.Assert(t, u.Backup[5:] != "edgewhere.fr")
+
+	assert.Assert(t, regexp.MustCompile(`^[0-9]{10}$`).MatchString(u.Phone) && u.Phone != "0612345678", u.Phone)
+	assert.Assert(t, u.Account != 1234567890123456 && u.Account >= 1000000000000000 && u.Account <= 9999999999999999, u.Account)
+	assert.Assert(t, u.Score != -4242 && u.Score <= -1000 && u.Score >= -9999, u.Score)
+	assert.Assert(t, u.Balance != -1)
+	assert.Assert(t, u.Codes["a"] != 1 || u.Codes["b"] != 65535)
+
+	assert.Assert(t, u.Home.Street != "1 rue de la Paix" && u.Home.City == "Paris")
+	assert.Assert(t, u.Previous[0].Street != "Baker Street" && u.Previous[0].City == "London")
+	assert.Assert(t, u.Offices["hq"].Street != "Main Street")
+	assert.Assert(t, u.Extra.(*address).Street != "Broadway")
+	assert.Assert(t, u.By != "admin")
+	assert.Equal(t, u.Notes, "untouched")
+	assert.Equal(t, u.Ignored, "untouched")
+	assert.Equal(t, u.internal, "untouched")
+
+	err = feistel.DecryptStruct(u, registry)
+	assert.NilError(t, err)
+	original := newUser()
+	original.Email = "john.doe+news@example.com"
+	assert.Assert(t, reflect.DeepEqual(u, original))
+
+	// Shared and cyclic values are only processed once
+	home := &address{Street: "Wall Street"}
+	shared := struct {
+		Home, Work *address
+		Self       any
+	}{Home: home, Work: home}
+	shared.Self = &shared
+	err = feistel.EncryptStruct(&shared, registry)
+	assert.NilError(t, err)
+	assert.Assert(t, home.Street != "Wall Street")
+	err = feistel.DecryptStruct(&shared, registry)
+	assert.NilError(t, err)
+	assert.Equal(t, home.Street, "Wall Street")
+
+	// Works with a keyring
+	keyring, _ := feistel.NewKeyring(feistel.DEFAULT_FIELD_KEY, registry[feistel.DEFAULT_FIELD_KEY])
+	_ = keyring.Add("pii", registry["pii"])
+	u = newUser()
+	err = feistel.EncryptStruct(u, keyring)
+	assert.NilError(t, err)
+	assert.Equal(t, u.Name, expected.String())
+}
+
+// TestEncryptStructErrors ...
+func TestEncryptStructErrors(t *testing.T) {
+	registry := newRegistry()
+
+	err := feistel.EncryptStruct(*newUser(), registry)
+	assert.Error(t, err, exception.NewInvalidTargetError().Error())
+	err = feistel.EncryptStruct((*user)(nil), registry)
+	assert.Error(t, err, exception.NewInvalidTargetError().Error())
+
+	err = feistel.EncryptStruct(newUser(), feistel.Ciphers{feistel.DEFAULT_FIELD_KEY: registry[feistel.DEFAULT_FIELD_KEY]})
+	assert.Error(t, err, exception.NewUnknownKeyIDError().Error())
+
+	err = feistel.EncryptStruct(&struct {
+		Name string `feistel:"fpe"`
+	}{"Edgewhere"}, feistel.Ciphers{feistel.DEFAULT_FIELD_KEY: feistel.NewCipher(katKey, 10)})
+	assert.Error(t, err, exception.NewWrongCipherParametersError().Error())
+
+	for _, target := range []any{
+		&struct {
+			Name string `feistel:"unknown"`
+		}{"Edgewhere"},
+		&struct {
+			Name string `feistel:"fpe,digits"`
+		}{"Edgewhere"},
+		&struct {
+			Age int `feistel:"email"`
+		}{42},
+		&struct {
+			Name string `feistel:"number"`
+		}{"Edgewhere"},
+		&struct {
+			Data []byte `feistel:"fpe"`
+		}{[]byte("Edgewhere")},
+	} {
+		err = feistel.EncryptStruct(target, registry)
+		assert.Error(t, err, exception.NewInvalidTagError().Error())
+	}
+
+	err = feistel.EncryptStruct(&struct {
+		Phone string `feistel:"number,digits"`
+	}{"+33612345678"}, registry)
+	assert.Error(t, err, exception.NewOutOfRangeError().Error())
+
+	// Single-digit values require the small-domain mode
+	single := struct {
+		Age uint8 `feistel:"number,digits"`
+	}{7}
+	err = feistel.EncryptStruct(&single, registry)
+	assert.Error(t, err, exception.NewTooShortToEncryptError().Error())
+	small := feistel.NewFPECipher(hash.SHA_256, katKey, 10)
+	small.SmallDomain = true
+	err = feistel.EncryptStruct(&single, feistel.Ciphers{feistel.DEFAULT_FIELD_KEY: small})
+	assert.NilError(t, err)
+	assert.Assert(t, single.Age <= 9)
+	err = feistel.DecryptStruct(&single, feistel.Ciphers{feistel.DEFAULT_FIELD_KEY: small})
+	assert.NilError(t, err)
+	assert.Equal(t, single.Age, uint8(7))
+}
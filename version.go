@@ -0,0 +1,193 @@
+package feistel
+
+import (
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/exception"
+	"github.com/cyrildever/feistel/keys"
+)
+
+//--- TYPES
+
+// Version identifies a frozen set of algorithm options, ie. the round function, its expansion and mask, the key schedule
+// and the handling of very short inputs.
+//
+// Versioning policy:
+//   - a released version is frozen: its known-answer tests in version_test.go must never be modified;
+//   - any change to the bytes produced by a cipher (new round function, expansion, mask, key schedule, etc.) requires
+//     a new version number, the previous ones remaining available for decryption forever;
+//   - the legacy constructors (NewCipher, NewCustomCipher, NewFPECipher) and zero-valued options always stay on V1_LEGACY.
+type Version int
+
+const (
+	// UNVERSIONED is returned for ciphers whose options don't match any released version
+	UNVERSIONED Version = 0
+	// V1_LEGACY is the original algorithm, compatible with the Typescript, Scala and Python implementations
+	V1_LEGACY Version = 1
+	// V2 uses the HMAC_ROUND function with COUNTER_EXPANSION_V1 and RAW_MASK_V1, HKDF-derived round keys when a master
+	// key is used, and the small-domain mode of the FPECipher
+	V2 Version = 2
+
+	LATEST_VERSION = V2
+)
+
+type options struct {
+	round       Round
+	expansion   Expansion
+	mask        Mask
+	derivedKeys bool
+	smallDomain bool
+}
+
+var versions = map[Version]options{
+	V1_LEGACY: {},
+	V2: {
+		round:       HMAC_ROUND,
+		expansion:   COUNTER_EXPANSION_V1,
+		mask:        RAW_MASK_V1,
+		derivedKeys: true,
+		smallDomain: true,
+	},
+}
+
+//--- METHODS
+
+// IsValid ...
+func (v Version) IsValid() bool {
+	_, ok := versions[v]
+	return ok
+}
+
+// Version returns the version matching the cipher's options, or UNVERSIONED
+func (c Cipher) Version() Version {
+	return versionOf(options{round: c.Round, expansion: c.Expansion, mask: c.Mask}, c.schedule(), nil)
+}
+
+// Version returns the version matching the cipher's options, or UNVERSIONED
+func (cc CustomCipher) Version() Version {
+	return versionOf(options{round: cc.Round, expansion: cc.Expansion, mask: cc.Mask}, cc.schedule(), nil)
+}
+
+// Version returns the version matching the cipher's options, or UNVERSIONED
+func (f FPECipher) Version() Version {
+	return versionOf(options{round: f.Round, expansion: f.Expansion, mask: f.Mask}, f.schedule(), &f.SmallDomain)
+}
+
+//--- FUNCTIONS
+
+// NewVersionedCipher ...
+func NewVersionedCipher(version Version, engine hash.Engine, key string, rounds int) (*Cipher, error) {
+	opts, ok := versions[version]
+	if !ok {
+		return nil, exception.NewUnknownVersionError()
+	}
+	cipher := NewCipherWithEngine(engine, key, rounds)
+	cipher.Round, cipher.Expansion, cipher.Mask = opts.round, opts.expansion, opts.mask
+	if opts.derivedKeys {
+		schedule := NewHKDFKeys(orDefault(engine), key)
+		if !schedule.isValid() {
+			return nil, exception.NewWrongCipherParametersError()
+		}
+		cipher.Schedule = schedule
+	}
+	return cipher, nil
+}
+
+// NewVersionedCustomCipher ...
+func NewVersionedCustomCipher(version Version, engine hash.Engine, keys []string) (*CustomCipher, error) {
+	opts, ok := versions[version]
+	if !ok {
+		return nil, exception.NewUnknownVersionError()
+	}
+	cipher := NewCustomCipherWithEngine(engine, keys)
+	cipher.Round, cipher.Expansion, cipher.Mask = opts.round, opts.expansion, opts.mask
+	return cipher, nil
+}
+
+// NewVersionedFPECipher ...
+func NewVersionedFPECipher(version Version, engine hash.Engine, key string, rounds int) (*FPECipher, error) {
+	opts, ok := versions[version]
+	if !ok {
+		return nil, exception.NewUnknownVersionError()
+	}
+	cipher := NewFPECipher(engine, key, rounds)
+	cipher.Round, cipher.Expansion, cipher.Mask = opts.round, opts.expansion, opts.mask
+	if opts.derivedKeys {
+		schedule := NewHKDFKeys(orDefault(engine), key)
+		if !schedule.isValid() {
+			return nil, exception.NewWrongCipherParametersError()
+		}
+		cipher.Schedule = schedule
+	}
+	cipher.SmallDomain = opts.smallDomain
+	return cipher, nil
+}
+
+// NewVersionedSecretCipher ...
+func NewVersionedSecretCipher(version Version, engine hash.Engine, secret *keys.Secret, rounds int) (*Cipher, error) {
+	opts, ok := versions[version]
+	if !ok {
+		return nil, exception.NewUnknownVersionError()
+	}
+	cipher := NewSecretCipher(engine, secret, rounds)
+	cipher.Round, cipher.Expansion, cipher.Mask = opts.round, opts.expansion, opts.mask
+	if opts.derivedKeys {
+		schedule := &HKDFKeys{Engine: orDefault(engine), Secret: secret}
+		if !schedule.isValid() {
+			return nil, exception.NewWrongCipherParametersError()
+		}
+		cipher.Schedule = schedule
+	}
+	return cipher, nil
+}
+
+// NewVersionedSecretCustomCipher ...
+func NewVersionedSecretCustomCipher(version Version, engine hash.Engine, secrets []*keys.Secret) (*CustomCipher, error) {
+	opts, ok := versions[version]
+	if !ok {
+		return nil, exception.NewUnknownVersionError()
+	}
+	cipher := NewSecretCustomCipher(engine, secrets)
+	cipher.Round, cipher.Expansion, cipher.Mask = opts.round, opts.expansion, opts.mask
+	return cipher, nil
+}
+
+// NewVersionedSecretFPECipher ...
+func NewVersionedSecretFPECipher(version Version, engine hash.Engine, secret *keys.Secret, rounds int) (*FPECipher, error) {
+	opts, ok := versions[version]
+	if !ok {
+		return nil, exception.NewUnknownVersionError()
+	}
+	cipher := NewSecretFPECipher(engine, secret, rounds)
+	cipher.Round, cipher.Expansion, cipher.Mask = opts.round, opts.expansion, opts.mask
+	if opts.derivedKeys {
+		schedule := &HKDFKeys{Engine: orDefault(engine), Secret: secret}
+		if !schedule.isValid() {
+			return nil, exception.NewWrongCipherParametersError()
+		}
+		cipher.Schedule = schedule
+	}
+	cipher.SmallDomain = opts.smallDomain
+	return cipher, nil
+}
+
+// versionOf returns the version matching the passed options, explicit round keys being accepted by any version
+// and the small-domain flag being ignored if nil, ie. when it's not relevant to the cipher type
+func versionOf(opts options, schedule KeySchedule, smallDomain *bool) Version {
+	explicit := false
+	switch schedule.(type) {
+	case RoundKeys, SecretKeys:
+		explicit = true
+	}
+	derived := false
+	switch schedule.(type) {
+	case HKDFKeys, *HKDFKeys:
+		derived = true
+	}
+	for version, expected := range versions {
+		if opts.round == expected.round && opts.expansion == expected.expansion && opts.mask == expected.mask &&
+			(explicit || derived == expected.derivedKeys) && (smallDomain == nil || *smallDomain == expected.smallDomain) {
+			return version
+		}
+	}
+	return UNVERSIONED
+}
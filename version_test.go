@@ -0,0 +1,199 @@
+package feistel_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cyrildever/feistel"
+	"github.com/cyrildever/feistel/common/utils/base256"
+	"github.com/cyrildever/feistel/common/utils/hash"
+	"github.com/cyrildever/feistel/exception"
+	"github.com/cyrildever/feistel/keys"
+	utls "github.com/cyrildever/go-utls/common/utils"
+	"gotest.tools/assert"
+)
+
+// These known answers are FROZEN: they must never be modified (see the versioning policy in version.go)
+
+const (
+	katKey      = "8ed9dcc1701c064f0fd7ae235f15143f989920e0ee9658bb7882c8d7d5f05692"
+	katShortKey = "some-32-byte-long-key-to-be-safe"
+	katSource   = "Edgewhere"
+)
+
+var katKeys = []string{
+	"1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
+	"9876543210fedcba9876543210fedcba9876543210fedcba9876543210fedcba",
+	"abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789",
+}
+
+// TestV1KnownAnswers ...
+func TestV1KnownAnswers(t *testing.T) {
+	cipher, err := feistel.NewVersionedCipher(feistel.V1_LEGACY, hash.SHA_256, katKey, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, cipher.Version(), feistel.V1_LEGACY)
+	assert.Equal(t, feistel.NewCipher(katKey, 10).Version(), feistel.V1_LEGACY)
+	found, err := cipher.Encrypt(katSource)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "3d7c0a0f51415a521054")
+	legacy, _ := feistel.NewCipher(katKey, 10).Encrypt(katSource)
+	assert.DeepEqual(t, found, legacy)
+
+	custom, err := feistel.NewVersionedCustomCipher(feistel.V1_LEGACY, hash.SHA_256, katKeys)
+	assert.NilError(t, err)
+	assert.Equal(t, custom.Version(), feistel.V1_LEGACY)
+	found, err = custom.Encrypt(katSource)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "445951465c5a19613633")
+
+	vectors := []struct {
+		engine   hash.Engine
+		key      string
+		rounds   int
+		source   string
+		expected string
+	}{
+		{hash.SHA_256, katKey, 10, katSource, "2a5d07024f5a501409"},
+		{hash.BLAKE2b, katKey, 10, katSource, "765309034f0f534e5e"},
+		{hash.SHA_256, katShortKey, 128, "my-source-data", "3e7d7e7b637c766d6c7961312165"},
+	}
+	for _, vector := range vectors {
+		fpe, err := feistel.NewVersionedFPECipher(feistel.V1_LEGACY, vector.engine, vector.key, vector.rounds)
+		assert.NilError(t, err)
+		assert.Equal(t, fpe.Version(), feistel.V1_LEGACY)
+		obfuscated, err := fpe.EncryptString(vector.source)
+		assert.NilError(t, err)
+		assert.Equal(t, obfuscated.ToHex(), vector.expected)
+		legacy, _ := feistel.NewFPECipher(vector.engine, vector.key, vector.rounds).EncryptString(vector.source)
+		assert.Equal(t, obfuscated, legacy)
+	}
+
+	fpe, _ := feistel.NewVersionedFPECipher(feistel.V1_LEGACY, hash.SHA_256, katShortKey, 128)
+	number, err := fpe.EncryptNumber(123456789)
+	assert.NilError(t, err)
+	assert.Equal(t, number.Uint64(), uint64(22780178))
+	deciphered, err := fpe.Decrypt(base256.Readable("K¡(#q|r5*"))
+	assert.NilError(t, err)
+	assert.Assert(t, deciphered != katSource) // Not the same key
+
+	// Zero is the only one-byte ciphertext
+	fpe, _ = feistel.NewVersionedFPECipher(feistel.V1_LEGACY, hash.SHA_256, katKey, 10)
+	number, err = fpe.EncryptNumber(0)
+	assert.Error(t, err, exception.NewTooSmallToPreserveLengthError().Error())
+	assert.Equal(t, number.ToHex(), "68")
+	zero, err := fpe.DecryptNumber(number)
+	assert.NilError(t, err)
+	assert.Equal(t, zero, uint64(0))
+}
+
+// TestV1LongKnownAnswers covers the halves longer than the digest, ie. the repetition of the legacy round output
+func TestV1LongKnownAnswers(t *testing.T) {
+	source := strings.Repeat(katSource, 15) // 135 bytes
+
+	cipher, _ := feistel.NewVersionedCipher(feistel.V1_LEGACY, hash.SHA_256, katKey, 10)
+	found, err := cipher.Encrypt(source)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "6b7658535b465d0817067201565c115f5a4a0879500d541e0f0711032d545205485a0d1153755206051b5806160c705353571f5d541558720705074f530a10042c575b514101044e56715c5d59430908145b7c0f075d1951084401785c0705445451455f2f5001571005064551295d0301415e5616052152010c1e5d004c0a70085353175e0c1359")
+	deciphered, err := cipher.Decrypt(found)
+	assert.NilError(t, err)
+	assert.Equal(t, deciphered, source)
+
+	custom, _ := feistel.NewVersionedCustomCipher(feistel.V1_LEGACY, hash.SHA_256, katKeys)
+	found, err = custom.Encrypt(source)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "470a004a0371550553445c524203735d5200455e554b03755c5506125806130770075157425807110324070300410c574306275c5555470e554507755c0453115807175d5044646734783463206f4636603b773b6377314666343576323971374b373d33776a337565123e3c37246f642e3442363364236861733510636b6b7666672f3017656765")
+
+	vectors := []struct {
+		engine   hash.Engine
+		expected string
+	}{
+		{hash.SHA_256, "2d5a5855170605425472000307150801465327500850120f0b1b5e7c5300031b0655165e2c055500165451110877010b5446585011067106055d1a505611027b0c595a13530e140670550c0312590219062859560a1006551458775f5353440a564006785d0550420b0a475d2450020f1400041f0073035652450e0b115b2154520f480c5e1903"},
+		{hash.KECCAK, "730b51524f5f5148022e55010217585811502505050e46070744007d0c0d55135c511b0e760050071d5a0b455c24570802440f071151270b5602160c01425d2852085314055541572a0e0750470d0615502e545d054d0151155278060a004f580e16537052075712000a130c230a045913545a17572e0d500b1652061502230204581d0b084256"},
+		{hash.SHA_3, "2e52505e1d0b0f4e0b24500559165f0c4f02265c5c5b44545613542908005f125605450372015504415d554e0c26035c0d105853160d225e5353405d5241532e0f5152115c554d0476030b5b1307541507760700501408014c0d285d0c5141025446552a07520b10030547097658545b125959450e7a595702480f06455478075d5b140e51425a"},
+	}
+	for _, vector := range vectors {
+		fpe, err := feistel.NewVersionedFPECipher(feistel.V1_LEGACY, vector.engine, katKey, 10)
+		assert.NilError(t, err)
+		obfuscated, err := fpe.EncryptString(source)
+		assert.NilError(t, err)
+		assert.Equal(t, obfuscated.ToHex(), vector.expected)
+		deciphered, err := fpe.DecryptString(obfuscated)
+		assert.NilError(t, err)
+		assert.Equal(t, deciphered, source)
+	}
+
+	fpe, _ := feistel.NewVersionedFPECipher(feistel.V1_LEGACY, hash.SHA_256, katKey, 10)
+	number, err := fpe.EncryptNumber(18446744073709551615)
+	assert.NilError(t, err)
+	assert.Equal(t, number.ToHex(), "9d9195cdcc9bcf9b")
+	assert.Equal(t, number.Uint64(), uint64(11354020846711328667))
+	fpe, _ = feistel.NewVersionedFPECipher(feistel.V1_LEGACY, hash.KECCAK, katKey, 10)
+	number, err = fpe.EncryptNumber(1234567890123456789)
+	assert.NilError(t, err)
+	assert.Equal(t, number.Uint64(), uint64(8220238943740933155))
+	n, err := fpe.DecryptNumber(number)
+	assert.NilError(t, err)
+	assert.Equal(t, n, uint64(1234567890123456789))
+}
+
+// TestV2KnownAnswers ...
+func TestV2KnownAnswers(t *testing.T) {
+	cipher, err := feistel.NewVersionedCipher(feistel.V2, hash.SHA_256, katKey, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, cipher.Version(), feistel.V2)
+	found, err := cipher.Encrypt(katSource)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "ca99c7868ae87e21ea64")
+
+	custom, err := feistel.NewVersionedCustomCipher(feistel.V2, hash.SHA_256, katKeys)
+	assert.NilError(t, err)
+	assert.Equal(t, custom.Version(), feistel.V2)
+	found, err = custom.Encrypt(katSource)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "d6059a37a5dbafd87eac")
+
+	fpe, err := feistel.NewVersionedFPECipher(feistel.V2, hash.SHA_256, katKey, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, fpe.Version(), feistel.V2)
+	obfuscated, err := fpe.EncryptString(katSource)
+	assert.NilError(t, err)
+	assert.Equal(t, obfuscated.ToHex(), "6c61cf8cec6b28d977")
+	obfuscated, err = fpe.EncryptString("a")
+	assert.NilError(t, err)
+	assert.Equal(t, obfuscated.ToHex(), "26")
+	number, err := fpe.EncryptNumber(123456789)
+	assert.NilError(t, err)
+	assert.Equal(t, number.Uint64(), uint64(4193650174))
+}
+
+// TestVersion ...
+func TestVersion(t *testing.T) {
+	assert.Assert(t, feistel.LATEST_VERSION.IsValid())
+	assert.Assert(t, !feistel.UNVERSIONED.IsValid())
+
+	_, err := feistel.NewVersionedFPECipher(feistel.Version(99), hash.SHA_256, katKey, 10)
+	assert.Error(t, err, "unknown algorithm version")
+	_, ok := err.(*exception.UnknownVersionError)
+	assert.Assert(t, ok)
+
+	// Mixing options of different versions
+	cipher := feistel.NewCipher(katKey, 10)
+	cipher.Round = feistel.HMAC_ROUND
+	assert.Equal(t, cipher.Version(), feistel.UNVERSIONED)
+	fpe, _ := feistel.NewVersionedFPECipher(feistel.V2, hash.SHA_256, katKey, 10)
+	fpe.SmallDomain = false
+	assert.Equal(t, fpe.Version(), feistel.UNVERSIONED)
+
+	// The derived keys use the default engine too and are checked upfront
+	defaulted, err := feistel.NewVersionedCipher(feistel.V2, "", katKey, 10)
+	assert.NilError(t, err)
+	found, err := defaulted.Encrypt(katSource)
+	assert.NilError(t, err)
+	assert.Equal(t, utls.ToHex(found), "ca99c7868ae87e21ea64")
+	_, err = feistel.NewVersionedCipher(feistel.V2, hash.SHA_256, "", 10)
+	assert.Error(t, err, exception.NewWrongCipherParametersError().Error())
+	_, err = feistel.NewVersionedFPECipher(feistel.V2, "unknown", katKey, 10)
+	assert.Error(t, err, exception.NewWrongCipherParametersError().Error())
+	_, err = feistel.NewVersionedSecretFPECipher(feistel.V2, hash.SHA_256, keys.NewSecret(nil), 10)
+	assert.Error(t, err, exception.NewWrongCipherParametersError().Error())
+}